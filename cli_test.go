@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlexxIT/go2rtc/pkg/core"
+	"github.com/eduard256/strix/pkg/tester"
+)
+
+type cliProducer struct{}
+
+func (cliProducer) GetMedias() []*core.Media { return nil }
+func (cliProducer) GetTrack(*core.Media, *core.Codec) (*core.Receiver, error) {
+	return nil, errors.New("cli: no track")
+}
+func (cliProducer) Start() error { return nil }
+func (cliProducer) Stop() error  { return nil }
+
+func TestCLITest(t *testing.T) {
+	// only "alive" path answers
+	tester.RegisterSource("cli", func(rawURL string) (core.Producer, error) {
+		if strings.HasSuffix(rawURL, "/alive") {
+			return cliProducer{}, nil
+		}
+		return nil, errors.New("cli: no stream")
+	})
+
+	list := filepath.Join(t.TempDir(), "urls.txt")
+	if err := os.WriteFile(list, []byte("# cameras\ncli://10.0.0.6/alive\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args  []string
+		code  int
+		alive int
+	}{
+		{[]string{"cli://10.0.0.5/dead"}, 1, 0},
+		{[]string{"-quick", "cli://10.0.0.5/dead", "cli://10.0.0.5/alive"}, 0, 1},
+		{[]string{"-f", list, "cli://10.0.0.5/dead"}, 0, 1},
+		{nil, 2, -1},
+		{[]string{"-unknown", "cli://10.0.0.5/alive"}, 2, -1},
+		{[]string{"-f", filepath.Join(t.TempDir(), "missing.txt")}, 1, -1},
+	}
+
+	for _, test := range tests {
+		var stdout bytes.Buffer
+		if code := cliTest(test.args, &stdout); code != test.code {
+			t.Errorf("%q: exit code %d, want %d", test.args, code, test.code)
+		}
+
+		if test.alive < 0 {
+			if stdout.Len() > 0 {
+				t.Errorf("%q: stdout = %s", test.args, stdout.String())
+			}
+			continue
+		}
+
+		var s struct {
+			Status string `json:"status"`
+			Alive  int    `json:"alive"`
+		}
+		if err := json.Unmarshal(stdout.Bytes(), &s); err != nil {
+			t.Errorf("%q: %v: %s", test.args, err, stdout.String())
+			continue
+		}
+		if s.Status != "done" || s.Alive != test.alive {
+			t.Errorf("%q: session %+v, want %d alive", test.args, s, test.alive)
+		}
+	}
+}
@@ -2,8 +2,13 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/eduard256/strix/internal/app"
@@ -21,11 +26,23 @@ func Init() {
 
 	HandleFunc("api", apiHandler)
 	HandleFunc("api/health", apiHealth)
+	HandleFunc("api/ready", apiReady)
 	HandleFunc("api/log", apiLog)
 
+	initOpenAPI()
 	initStatic()
 
-	Handler = middlewareCORS(http.DefaultServeMux)
+	if s := app.Env("STRIX_MAX_BODY_MB", ""); s != "" {
+		if mb, err := strconv.Atoi(s); err == nil && mb > 0 {
+			maxBody = int64(mb) << 20
+		} else {
+			log.Warn().Str("value", s).Msg("[api] invalid STRIX_MAX_BODY_MB, using default")
+		}
+	}
+
+	corsOrigins = parseOrigins(app.Env("STRIX_CORS_ORIGINS", ""))
+
+	Handler = middlewareCORS(middlewareLimit(http.DefaultServeMux))
 
 	if log.Trace().Enabled() {
 		Handler = middlewareLog(Handler)
@@ -74,9 +91,67 @@ func Error(w http.ResponseWriter, err error, code int) {
 	http.Error(w, err.Error(), code)
 }
 
+// BodyError writes 413 for body over STRIX_MAX_BODY_MB, 400 for other read and decode errors
+func BodyError(w http.ResponseWriter, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// FieldError describes one invalid request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"` // "required", "invalid", "min", "enum"
+	Message string `json:"message"`
+}
+
+// ValidationError writes 400 response. Clients with "Accept: application/json"
+// get {"errors": [...]}, others get plain text like http.Error.
+func ValidationError(w http.ResponseWriter, r *http.Request, errs ...FieldError) {
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]any{"errors": errs})
+		return
+	}
+
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Message
+	}
+	http.Error(w, strings.Join(msgs, "; "), http.StatusBadRequest)
+}
+
+// corsOrigins -- allowed origins from STRIX_CORS_ORIGINS, nil - any origin
+var corsOrigins map[string]bool
+
+// parseOrigins parses STRIX_CORS_ORIGINS, nil for empty or "*".
+// ex. "http://homeassistant.local:8123,https://nvr.example.com"
+func parseOrigins(s string) map[string]bool {
+	if s == "" || s == "*" {
+		return nil
+	}
+	origins := map[string]bool{}
+	for _, origin := range strings.Split(s, ",") {
+		origins[strings.TrimSuffix(strings.TrimSpace(origin), "/")] = true
+	}
+	return origins
+}
+
 func middlewareCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if corsOrigins == nil {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			// without header browser blocks response for other origins
+			w.Header().Add("Vary", "Origin")
+			if origin := r.Header.Get("Origin"); corsOrigins[origin] {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 		if r.Method == "OPTIONS" {
@@ -86,22 +161,81 @@ func middlewareCORS(next http.Handler) http.Handler {
 	})
 }
 
+// maxBody limits request body, test session with 20,000 URLs is about 2MB
+var maxBody int64 = 10 << 20
+
+// middlewareLimit rejects big bodies with 413, chunked bodies fail on read, see BodyError
+func middlewareLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxBody {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+		next.ServeHTTP(w, r)
+	})
+}
+
 func middlewareLog(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Trace().Msgf("[api] %s %s %s", r.Method, r.URL, r.RemoteAddr)
+		// stdout is not masked by SecretWriter, ex. "api/streams?user=admin&pass=***"
+		log.Trace().Msgf("[api] %s %s %s", r.Method, app.MaskURL(r.URL.String()), r.RemoteAddr)
 		next.ServeHTTP(w, r)
 	})
 }
 
 func apiHandler(w http.ResponseWriter, r *http.Request) {
-	ResponseJSON(w, app.Info)
+	ResponseJSON(w, app.Info())
 }
 
 func apiHealth(w http.ResponseWriter, r *http.Request) {
-	ResponseJSON(w, map[string]any{
+	info := map[string]any{
 		"version": app.Version,
 		"uptime":  time.Since(app.StartTime).Truncate(time.Second).String(),
-	})
+	}
+	// ffmpeg build helps with screenshot issues, set by test module
+	if v, ok := app.Info()["ffmpeg"]; ok {
+		info["ffmpeg"] = v
+	}
+	ResponseJSON(w, info)
+}
+
+var ready atomic.Bool
+var readyChecks = map[string]func() error{}
+var readyMu sync.Mutex
+
+// AddReadyCheck registers check for /api/ready, ex. database is readable
+func AddReadyCheck(name string, check func() error) {
+	readyMu.Lock()
+	readyChecks[name] = check
+	readyMu.Unlock()
+}
+
+// SetReady is called when all modules are initialized
+func SetReady() {
+	ready.Store(true)
+}
+
+// apiReady -- readiness, unlike /api/health returns 503 until Strix can serve requests
+func apiReady(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]string{}
+	ok := ready.Load()
+
+	readyMu.Lock()
+	for name, check := range readyChecks {
+		if err := check(); err != nil {
+			checks[name] = err.Error()
+			ok = false
+		} else {
+			checks[name] = "ok"
+		}
+	}
+	readyMu.Unlock()
+
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	ResponseJSON(w, map[string]any{"ready": ok, "checks": checks})
 }
 
 func apiLog(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/eduard256/strix/pkg/probe"
+	"github.com/eduard256/strix/pkg/tester"
+)
+
+// TestOpenAPISchemas checks spec properties against JSON fields of Go structs
+func TestOpenAPISchemas(t *testing.T) {
+	var spec struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(openapi, &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]any{
+		"Session":       tester.Session{},
+		"Result":        tester.Result{},
+		"ProbeResponse": probe.Response{},
+	}
+
+	for name, v := range tests {
+		schema, ok := spec.Components.Schemas[name]
+		if !ok {
+			t.Errorf("%s: no schema", name)
+			continue
+		}
+
+		var props []string
+		for k := range schema.Properties {
+			props = append(props, k)
+		}
+		slices.Sort(props)
+
+		if fields := jsonFields(reflect.TypeOf(v)); !slices.Equal(props, fields) {
+			t.Errorf("%s: spec properties %v, struct fields %v", name, props, fields)
+		}
+	}
+}
+
+// jsonFields returns sorted JSON names of exported struct fields
+func jsonFields(typ reflect.Type) []string {
+	var names []string
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
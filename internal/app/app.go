@@ -1,8 +1,10 @@
 package app
 
 import (
+	"maps"
 	"os"
 	"runtime"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -12,22 +14,35 @@ var Version string
 
 var Logger zerolog.Logger
 
-var Info = map[string]any{}
+var info = map[string]any{}
+var infoMu sync.RWMutex
 
 var StartTime = time.Now()
 
-// DB is the shared SQLite database path
-var DB string
+// DB is the shared SQLite database path, STRIX_DB_PATH
+var DB = Env("STRIX_DB_PATH", "cameras.db")
 
 func Init() {
 	initLogger()
 
-	Info["version"] = Version
-	Info["platform"] = runtime.GOARCH
+	SetInfo("version", Version)
+	SetInfo("platform", runtime.GOARCH)
 
 	Logger.Info().Str("version", Version).Str("platform", runtime.GOARCH).Msg("[app] start")
+}
+
+// SetInfo adds value to /api info, modules may call it after API server has started
+func SetInfo(key string, value any) {
+	infoMu.Lock()
+	info[key] = value
+	infoMu.Unlock()
+}
 
-	DB = Env("STRIX_DB_PATH", "cameras.db")
+// Info returns copy of app info, ex. {"version": "2.0.0", "platform": "amd64"}
+func Info() map[string]any {
+	infoMu.RLock()
+	defer infoMu.RUnlock()
+	return maps.Clone(info)
 }
 
 func Env(key, def string) string {
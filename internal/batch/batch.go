@@ -0,0 +1,167 @@
+package batch
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/eduard256/strix/internal/search"
+	"github.com/eduard256/strix/pkg/camdb"
+	"github.com/eduard256/strix/pkg/tester"
+)
+
+// Target -- one camera row from batch file
+type Target struct {
+	IP      string `json:"ip"`
+	Model   string `json:"model"`
+	User    string `json:"-"`
+	Pass    string `json:"-"`
+	Channel int    `json:"channel"` // -1 for STRIX_DEFAULT_CHANNEL
+}
+
+// Result -- test session for one camera, or error if streams can't be built
+type Result struct {
+	Target
+	ID      string          `json:"id,omitempty"` // database ID for model, ex. "m:hikvision:DS-2CD2032-I"
+	Error   string          `json:"error,omitempty"`
+	Session *tester.Session `json:"session,omitempty"`
+}
+
+// Run tests cameras with database stream patterns, parallel cameras at the same time.
+// Database is opened by search.Open
+func Run(targets []Target, parallel, hostLimit int) []*Result {
+	results := make([]*Result, len(targets))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(parallel, 1))
+
+	for i, t := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			results[i] = testTarget(t, hostLimit)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// testTarget builds stream URLs for camera model and tests them in one session
+func testTarget(t Target, hostLimit int) *Result {
+	r := &Result{Target: t}
+
+	ctx := context.Background()
+
+	var err error
+	if r.ID, err = search.ResolveModel(ctx, t.Model); err != nil {
+		r.Error = err.Error()
+		return r
+	}
+
+	ip := t.IP
+	if addr := net.ParseIP(ip); addr != nil && addr.To4() == nil {
+		ip = "[" + ip + "]" // IPv6 in URL host
+	}
+
+	params := &camdb.StreamParams{
+		IDs:     r.ID,
+		IP:      ip,
+		User:    t.User,
+		Pass:    t.Pass,
+		Channel: t.Channel,
+	}
+	urls, _, err := search.Streams(ctx, params)
+	r.Channel = params.Channel // default channel in report
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+
+	s := tester.NewSession(t.IP, len(urls))
+	s.HostLimit = hostLimit
+	s.NoScreens = true
+
+	tester.RunWorkers(s, urls)
+
+	r.Session = s
+	return r
+}
+
+// ReadTargets reads CSV rows "ip,model,username,password,channel" from file,
+// "-" for stdin, only ip and model are required. Skips empty lines, # comments and header row.
+func ReadTargets(name string) ([]Target, error) {
+	f := os.Stdin
+	if name != "-" {
+		var err error
+		if f, err = os.Open(name); err != nil {
+			return nil, err
+		}
+		defer f.Close()
+	}
+	return ParseTargets(f)
+}
+
+func ParseTargets(r io.Reader) ([]Target, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var targets []Target
+
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+
+		if len(row) == 1 && strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+		if targets == nil && strings.EqualFold(strings.TrimSpace(row[0]), "ip") {
+			continue // header
+		}
+		if len(row) < 2 || len(row) > 5 {
+			return nil, fmt.Errorf("line %d: want ip,model[,username,password,channel]", line)
+		}
+
+		row = append(row, make([]string, 5-len(row))...)
+
+		t := Target{
+			IP:      strings.TrimSpace(row[0]),
+			Model:   strings.TrimSpace(row[1]),
+			User:    row[2],
+			Pass:    row[3],
+			Channel: -1,
+		}
+		if t.IP == "" || t.Model == "" {
+			return nil, fmt.Errorf("line %d: ip and model required", line)
+		}
+		if s := strings.TrimSpace(row[4]); s != "" {
+			if t.Channel, err = strconv.Atoi(s); err != nil || t.Channel < 0 {
+				return nil, fmt.Errorf("line %d: invalid channel: %s", line, s)
+			}
+		}
+
+		targets = append(targets, t)
+	}
+
+	if len(targets) == 0 {
+		return nil, errors.New("no cameras in file")
+	}
+	return targets, nil
+}
@@ -0,0 +1,47 @@
+package batch
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTargets(t *testing.T) {
+	f, err := os.Open("testdata/batch.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	targets, err := ParseTargets(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Target{
+		{IP: "192.168.1.100", Model: "hikvision DS-2CD2032", User: "admin", Pass: "12345", Channel: 1},
+		{IP: "192.168.1.101", Model: "b:dahua", User: "admin", Pass: "pa,ss", Channel: -1},
+		{IP: "fe80::1", Model: "m:hikvision:DS-2CD2032-I", Channel: -1},
+	}
+	if !reflect.DeepEqual(targets, want) {
+		t.Errorf("ParseTargets() = %+v, want %+v", targets, want)
+	}
+}
+
+func TestParseTargetsError(t *testing.T) {
+	tests := []string{
+		"",
+		"ip,model\n",
+		"192.168.1.100\n",
+		"192.168.1.100,,admin\n",
+		"192.168.1.100,b:dahua,admin,12345,main\n",
+		"192.168.1.100,b:dahua,admin,12345,1,extra\n",
+	}
+
+	for _, test := range tests {
+		if _, err := ParseTargets(strings.NewReader(test)); err == nil {
+			t.Errorf("ParseTargets(%q) want error", test)
+		}
+	}
+}
@@ -2,11 +2,13 @@ package generate
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/eduard256/strix/internal/api"
 	"github.com/eduard256/strix/internal/app"
 	gen "github.com/eduard256/strix/pkg/generate"
+	"github.com/eduard256/strix/pkg/tester"
 	"github.com/rs/zerolog"
 )
 
@@ -16,6 +18,7 @@ func Init() {
 	log = app.GetLogger("generate")
 
 	api.HandleFunc("api/generate", apiGenerate)
+	api.HandleFunc("api/generate/homeassistant", apiHomeAssistant)
 }
 
 func apiGenerate(w http.ResponseWriter, r *http.Request) {
@@ -26,7 +29,7 @@ func apiGenerate(w http.ResponseWriter, r *http.Request) {
 
 	var req gen.Request
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		api.BodyError(w, fmt.Errorf("invalid json: %w", err))
 		return
 	}
 
@@ -38,3 +41,27 @@ func apiGenerate(w http.ResponseWriter, r *http.Request) {
 
 	api.ResponseJSON(w, resp)
 }
+
+func apiHomeAssistant(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req gen.HARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.BodyError(w, fmt.Errorf("invalid json: %w", err))
+		return
+	}
+
+	// tested transport, not requested, HA must play stream the same way
+	req.RTSPTransport = tester.RTSPTransport
+
+	resp, err := gen.HomeAssistant(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	api.ResponseJSON(w, resp)
+}
@@ -33,12 +33,23 @@ func apiPair(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		api.BodyError(w, err)
 		return
 	}
 
-	if req.IP == "" || req.Port == 0 || req.DeviceID == "" || req.PIN == "" {
-		http.Error(w, "ip, port, device_id and pin required", http.StatusBadRequest)
+	var errs []api.FieldError
+	for _, f := range []struct {
+		name  string
+		empty bool
+	}{
+		{"ip", req.IP == ""}, {"port", req.Port == 0}, {"device_id", req.DeviceID == ""}, {"pin", req.PIN == ""},
+	} {
+		if f.empty {
+			errs = append(errs, api.FieldError{Field: f.name, Rule: "required", Message: f.name + " required"})
+		}
+	}
+	if errs != nil {
+		api.ValidationError(w, r, errs...)
 		return
 	}
 
@@ -3,8 +3,12 @@ package probe
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,9 +22,13 @@ import (
 
 const probeTimeout = 120 * time.Millisecond
 
+// resolveTimeout limits hostname lookup before probing, STRIX_RESOLVE_TIMEOUT
+var resolveTimeout = 2 * time.Second
+
 var log zerolog.Logger
 var db *sql.DB
 var ports []int
+var onvifPorts = []int{80, 8080, 8000, 2020, 8899}
 var detectors []func(*probe.Response) string
 
 func Init() {
@@ -33,6 +41,23 @@ func Init() {
 	}
 
 	ports = loadPorts()
+
+	if s := app.Env("STRIX_ONVIF_PORTS", ""); s != "" {
+		if p := parsePorts(s); len(p) > 0 {
+			onvifPorts = p
+		} else {
+			log.Warn().Str("value", s).Msg("[probe] invalid STRIX_ONVIF_PORTS, using defaults")
+		}
+	}
+
+	if s := app.Env("STRIX_RESOLVE_TIMEOUT", ""); s != "" {
+		if d, err := time.ParseDuration(s); err == nil && d > 0 {
+			resolveTimeout = d
+		} else {
+			log.Warn().Str("value", s).Msg("[probe] invalid STRIX_RESOLVE_TIMEOUT, using default")
+		}
+	}
+
 	// ONVIF detector (highest priority -- auto-discovers all streams)
 	detectors = append(detectors, func(r *probe.Response) string {
 		if r.Probes.ONVIF != nil {
@@ -52,25 +77,105 @@ func Init() {
 	})
 
 	api.HandleFunc("api/probe", apiProbe)
+	api.HandleFunc("api/probe/mdns", apiMDNS)
 }
 
 func apiProbe(w http.ResponseWriter, r *http.Request) {
-	ip := r.URL.Query().Get("ip")
+	q := r.URL.Query()
+
+	ip := q.Get("ip")
 	if ip == "" {
-		http.Error(w, "missing ip parameter", http.StatusBadRequest)
+		api.ValidationError(w, r, api.FieldError{Field: "ip", Rule: "required", Message: "missing ip parameter"})
 		return
 	}
 
+	// hostname is resolved once and probed by address, ex. "camera.local"
+	var host string
 	if net.ParseIP(ip) == nil {
-		http.Error(w, "invalid ip: "+ip, http.StatusBadRequest)
-		return
+		ctx, cancel := context.WithTimeout(r.Context(), resolveTimeout)
+		addr, err := probe.LookupHost(ctx, ip)
+		cancel()
+		if err != nil {
+			log.Debug().Err(err).Str("host", ip).Msg("[probe] resolve")
+			api.ValidationError(w, r, api.FieldError{Field: "ip", Rule: "invalid", Message: "invalid ip or unknown host: " + ip})
+			return
+		}
+		host, ip = ip, addr
+	}
+
+	onvif := onvifPorts
+	if s := q.Get("onvif_ports"); s != "" {
+		if onvif = parsePorts(s); len(onvif) == 0 {
+			api.ValidationError(w, r, api.FieldError{Field: "onvif_ports", Rule: "invalid", Message: "invalid onvif_ports: " + s})
+			return
+		}
 	}
 
-	result := runProbe(r.Context(), ip)
+	result := runProbe(r.Context(), ip, onvif)
+	result.Host = host
+
+	if db != nil && result.Reachable {
+		var err error
+		if result.Brand, err = probe.GuessBrand(r.Context(), db, result); err != nil {
+			log.Warn().Err(err).Msg("[probe] guess brand")
+		}
+	}
 	api.ResponseJSON(w, result)
 }
 
-func runProbe(parent context.Context, ip string) *probe.Response {
+// mdnsBrowseTime -- devices answer DNS-SD queries with random delay up to 500ms,
+// sleeping devices a bit later
+const mdnsBrowseTime = 2 * time.Second
+
+// apiMDNS lists devices that announce RTSP, HTTP, ONVIF or HomeKit services with mDNS,
+// for cameras that don't answer WS-Discovery, ex. consumer cameras with Bonjour.
+// With "Accept: text/event-stream" devices are sent as they answer.
+func apiMDNS(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), mdnsBrowseTime)
+	defer cancel()
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		streamMDNS(ctx, w)
+		return
+	}
+
+	devices, err := probe.BrowseMDNS(ctx, nil)
+	if err != nil {
+		log.Warn().Err(err).Msg("[probe] mdns browse")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if devices == nil {
+		devices = []*probe.MDNSDevice{}
+	}
+	api.ResponseJSON(w, map[string]any{"devices": devices})
+}
+
+// streamMDNS writes "device" event on every answer, same device is sent again
+// with all its services when it announces more, and "done" event at the end.
+// ex. curl -N -H "Accept: text/event-stream" "localhost:4567/api/probe/mdns"
+func streamMDNS(ctx context.Context, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	rc := http.NewResponseController(w)
+
+	devices, err := probe.BrowseMDNS(ctx, func(device *probe.MDNSDevice) {
+		b, _ := json.Marshal(device)
+		_, _ = fmt.Fprintf(w, "event: device\ndata: %s\n\n", b)
+		_ = rc.Flush()
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("[probe] mdns browse")
+		_, _ = fmt.Fprintf(w, "event: error\ndata: %q\n\n", err.Error())
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "event: done\ndata: {\"devices\":%d}\n\n", len(devices))
+}
+
+func runProbe(parent context.Context, ip string, onvifPorts []int) *probe.Response {
 	ctx, cancel := context.WithTimeout(parent, probeTimeout)
 	defer cancel()
 
@@ -124,7 +229,17 @@ func runProbe(parent context.Context, ip string) *probe.Response {
 		mu.Unlock()
 	})
 	run(func() {
+		// WS-Discovery and device service ports in parallel, discovery wins
+		ch := make(chan *probe.ONVIFResult, 1)
+		go func() {
+			r, _ := probe.ProbeONVIFPorts(fastCtx, ip, onvifPorts)
+			ch <- r
+		}()
+
 		r, _ := probe.ProbeONVIF(fastCtx, ip)
+		if r == nil {
+			r = <-ch
+		}
 		mu.Lock()
 		resp.Probes.ONVIF = r
 		mu.Unlock()
@@ -152,6 +267,19 @@ func runProbe(parent context.Context, ip string) *probe.Response {
 	return resp
 }
 
+// parsePorts parses comma-separated port list, ex. "80,8080,2020"
+func parsePorts(s string) []int {
+	var result []int
+	for _, v := range strings.Split(s, ",") {
+		port, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || port <= 0 || port > 65535 {
+			return nil
+		}
+		result = append(result, port)
+	}
+	return result
+}
+
 func loadPorts() []int {
 	if db == nil {
 		return defaultPorts()
@@ -1,8 +1,13 @@
 package search
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -17,24 +22,95 @@ import (
 var log zerolog.Logger
 var db *sql.DB
 
+// defaultChannel for requests without channel, STRIX_DEFAULT_CHANNEL
+var defaultChannel int
+
 func Init() {
+	if err := Open(); err != nil {
+		log.Fatal().Err(err).Msg("[search] db")
+	}
+
+	api.AddReadyCheck("db", func() error {
+		return db.QueryRow("SELECT 1 FROM brands LIMIT 1").Scan(new(int))
+	})
+
+	api.HandleFunc("api/search", apiSearch)
+	api.HandleFunc("api/streams", apiStreams)
+	api.HandleFunc("api/patterns", apiPatterns)
+	api.HandleFunc("api/streams/normalize", apiNormalize)
+}
+
+// Open opens camera database and applies STRIX_DEFAULT_* env,
+// shared by web server and "strix batch" CLI
+func Open() error {
 	log = app.GetLogger("search")
 
 	var err error
-	db, err = sql.Open("sqlite", "file:"+app.DB+"?mode=ro&immutable=1")
-	if err != nil {
-		log.Fatal().Err(err).Msg("[search] db open")
+	if db, err = sql.Open("sqlite", "file:"+app.DB+"?mode=ro&immutable=1"); err != nil {
+		return err
 	}
 
 	// verify DB is readable
 	var count int
 	if err = db.QueryRow("SELECT COUNT(*) FROM brands").Scan(&count); err != nil {
-		log.Fatal().Err(err).Msg("[search] db verify")
+		return err
 	}
 	log.Info().Int("brands", count).Msg("[search] loaded")
 
-	api.HandleFunc("api/search", apiSearch)
-	api.HandleFunc("api/streams", apiStreams)
+	if s := app.Env("STRIX_DEFAULT_CHANNEL", ""); s != "" {
+		if ch, err := strconv.Atoi(s); err == nil && ch >= 0 {
+			defaultChannel = ch
+		} else {
+			log.Warn().Str("value", s).Msg("[search] invalid STRIX_DEFAULT_CHANNEL, using 0")
+		}
+	}
+
+	if s := app.Env("STRIX_DEFAULT_SIZE", ""); s != "" {
+		if w, h := parseSize(s); w > 0 {
+			camdb.DefaultWidth, camdb.DefaultHeight = w, h
+		} else {
+			log.Warn().Str("value", s).Msg("[search] invalid STRIX_DEFAULT_SIZE, using 640x480")
+		}
+	}
+
+	return nil
+}
+
+// Streams builds stream URLs from database patterns, channel -1 is STRIX_DEFAULT_CHANNEL
+func Streams(ctx context.Context, params *camdb.StreamParams) ([]string, bool, error) {
+	if params.Channel < 0 {
+		params.Channel = defaultChannel
+	}
+	return camdb.BuildStreams(ctx, db, params)
+}
+
+// ResolveModel returns database ID as is, or the best search result for model name,
+// ex. "hikvision DS-2CD2032" -> "m:hikvision:DS-2CD2032-I"
+func ResolveModel(ctx context.Context, model string) (string, error) {
+	if len(model) > 2 && model[1] == ':' && strings.ContainsRune("bmp", rune(model[0])) {
+		return model, nil
+	}
+
+	found, err := camdb.SearchQuery(ctx, db, model)
+	if err != nil {
+		return "", err
+	}
+	if len(found) == 0 {
+		return "", errors.New("model not found: " + model)
+	}
+
+	// exact name first, ex. "hikvision" is brand with all patterns, not its first model
+	for _, f := range found {
+		if strings.EqualFold(f.Name, model) {
+			return f.ID, nil
+		}
+	}
+	for _, f := range found {
+		if f.Type == "model" {
+			return f.ID, nil
+		}
+	}
+	return found[0].ID, nil
 }
 
 func apiSearch(w http.ResponseWriter, r *http.Request) {
@@ -44,9 +120,9 @@ func apiSearch(w http.ResponseWriter, r *http.Request) {
 	var err error
 
 	if q == "" {
-		results, err = camdb.SearchAll(db)
+		results, err = camdb.SearchAll(r.Context(), db)
 	} else {
-		results, err = camdb.SearchQuery(db, q)
+		results, err = camdb.SearchQuery(r.Context(), db, q)
 	}
 
 	if err != nil {
@@ -60,19 +136,61 @@ func apiSearch(w http.ResponseWriter, r *http.Request) {
 func apiStreams(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 
+	var errs []api.FieldError
+
 	ids := q.Get("ids")
 	if ids == "" {
-		http.Error(w, "ids required", http.StatusBadRequest)
-		return
+		errs = append(errs, api.FieldError{Field: "ids", Rule: "required", Message: "ids required"})
 	}
 
 	ip := q.Get("ip")
 	if ip == "" {
-		http.Error(w, "ip required", http.StatusBadRequest)
-		return
+		errs = append(errs, api.FieldError{Field: "ip", Rule: "required", Message: "ip required"})
+	} else if addr := net.ParseIP(ip); addr != nil {
+		if addr.To4() == nil {
+			ip = "[" + ip + "]" // IPv6 in URL host
+		}
+	} else if !reHostname.MatchString(ip) || strings.Trim(ip, "0123456789.") == "" {
+		errs = append(errs, api.FieldError{Field: "ip", Rule: "invalid", Message: "ip must be IP address or hostname: " + ip})
+	}
+
+	var limit int
+	if s := q.Get("limit"); s != "" {
+		var err error
+		if limit, err = strconv.Atoi(s); err != nil || limit <= 0 {
+			errs = append(errs, api.FieldError{Field: "limit", Rule: "min", Message: "limit must be positive number"})
+		}
 	}
 
-	channel, _ := strconv.Atoi(q.Get("channel"))
+	// channel range, ex. "0-3" or "0,2"
+	channel := defaultChannel
+	var channels []int
+	if s := q.Get("channel"); strings.ContainsAny(s, "-,") {
+		if channels = parseRange(s); channels == nil {
+			errs = append(errs, api.FieldError{Field: "channel", Rule: "invalid", Message: "invalid channel range: " + s})
+		}
+	} else if s != "" {
+		channel, _ = strconv.Atoi(s)
+	}
+
+	var width, height int
+	if s := q.Get("size"); s != "" {
+		if width, height = parseSize(s); width == 0 {
+			errs = append(errs, api.FieldError{Field: "size", Rule: "invalid", Message: "size must be WIDTHxHEIGHT: " + s})
+		}
+	}
+
+	var subtypes []int
+	if s := q.Get("subtype"); s != "" {
+		if subtypes = parseRange(s); subtypes == nil {
+			errs = append(errs, api.FieldError{Field: "subtype", Rule: "invalid", Message: "invalid subtype: " + s})
+		}
+	}
+
+	if errs != nil {
+		api.ValidationError(w, r, errs...)
+		return
+	}
 
 	var portFilter map[int]bool
 	if ps := q.Get("ports"); ps != "" {
@@ -84,25 +202,148 @@ func apiStreams(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	streams, err := camdb.BuildStreams(db, &camdb.StreamParams{
+	// ex. "rtsp,rtsps" for tools that consume RTSP only
+	var protocols map[string]bool
+	if ps := q.Get("protocols"); ps != "" {
+		protocols = map[string]bool{}
+		for _, p := range strings.Split(ps, ",") {
+			protocols[strings.ToLower(strings.TrimSpace(p))] = true
+		}
+	}
+
+	streams, truncated, err := Streams(r.Context(), &camdb.StreamParams{
 		IDs:     ids,
 		IP:      ip,
 		User:    q.Get("user"),
 		Pass:    q.Get("pass"),
 		Channel: channel,
 		Ports:   portFilter,
+		Limit:   limit,
+
+		Channels: channels,
+		Subtypes: subtypes,
+		Anon:     q.Get("anon") == "1",
+		Width:    width,
+		Height:   height,
+
+		Protocols: protocols,
 	})
 
 	if err != nil {
-		status := http.StatusInternalServerError
-		if strings.Contains(err.Error(), "not found") {
-			status = http.StatusNotFound
-		} else if strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "unknown") {
-			status = http.StatusBadRequest
-		}
-		http.Error(w, err.Error(), status)
+		idError(w, err)
+		return
+	}
+
+	resp := map[string]any{"streams": streams}
+	if truncated {
+		resp["truncated"] = true
+	}
+	api.ResponseJSON(w, resp)
+}
+
+// apiPatterns -- database URL patterns for one ID with example URLs, for coverage debugging
+func apiPatterns(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		api.ValidationError(w, r, api.FieldError{Field: "id", Rule: "required", Message: "id required"})
+		return
+	}
+
+	patterns, err := camdb.Patterns(r.Context(), db, id)
+	if err != nil {
+		idError(w, err)
+		return
+	}
+
+	api.ResponseJSON(w, map[string]any{"patterns": patterns})
+}
+
+// POST /api/streams/normalize {"url": "rtsp://admin:p@ss#word@192.168.1.100/live"}
+// fixes pasted URL with special chars in credentials
+func apiNormalize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		URL string `json:"url"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.BodyError(w, err)
 		return
 	}
 
-	api.ResponseJSON(w, map[string]any{"streams": streams})
+	if req.URL == "" {
+		api.ValidationError(w, r, api.FieldError{Field: "url", Rule: "required", Message: "url required"})
+		return
+	}
+
+	u, err := camdb.NormalizeURL(req.URL)
+	if err != nil {
+		api.ValidationError(w, r, api.FieldError{Field: "url", Rule: "invalid", Message: err.Error()})
+		return
+	}
+
+	api.ResponseJSON(w, map[string]any{"url": u, "changed": u != req.URL})
+}
+
+// idError writes camdb error with status for unknown or invalid ID
+func idError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "not found") {
+		status = http.StatusNotFound
+	} else if strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "unknown") {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// parseSize parses resolution, ex. "1280x720", returns zeros if invalid
+func parseSize(s string) (int, int) {
+	ws, hs, ok := strings.Cut(strings.ToLower(s), "x")
+	if !ok {
+		return 0, 0
+	}
+	w, err1 := strconv.Atoi(ws)
+	h, err2 := strconv.Atoi(hs)
+	if err1 != nil || err2 != nil || w <= 0 || h <= 0 {
+		return 0, 0
+	}
+	return w, h
+}
+
+// reHostname -- DNS name, ex. "camera.local" or "nvr-1"
+var reHostname = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,62}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,62}[a-zA-Z0-9])?)*\.?$`)
+
+// maxRange limits values in channel and subtype ranges
+const maxRange = 64
+
+// maxRangeValue limits channel and subtype numbers
+const maxRangeValue = 9999
+
+// parseRange parses list of non-negative numbers and ranges, ex. "0-3", "0,1", "1-2,5"
+func parseRange(s string) []int {
+	var result []int
+	for _, part := range strings.Split(s, ",") {
+		from, to, ok := strings.Cut(strings.TrimSpace(part), "-")
+		a, err := strconv.Atoi(from)
+		if err != nil || a < 0 || a > maxRangeValue {
+			return nil
+		}
+		b := a
+		if ok {
+			if b, err = strconv.Atoi(to); err != nil || b < a || b > maxRangeValue {
+				return nil
+			}
+		}
+		if b-a >= maxRange-len(result) {
+			return nil
+		}
+		for i := a; i <= b; i++ {
+			result = append(result, i)
+		}
+	}
+	return result
 }
@@ -0,0 +1,48 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []int
+	}{
+		{"0", []int{0}},
+		{"0-3", []int{0, 1, 2, 3}},
+		{"0,2", []int{0, 2}},
+		{"1-2, 5", []int{1, 2, 5}},
+		{"3-3", []int{3}},
+		{"0-63", rangeInts(0, 63)},
+
+		{"", nil},
+		{"-1", nil},
+		{"3-1", nil},
+		{"a-b", nil},
+		{"0,,1", nil},
+		{"0-64", nil},
+		{"0-40,41-80", nil},
+		{"9999", []int{9999}},
+		{"10000", nil},
+		{"0-9223372036854775807", nil},
+		{"9223372036854775807", nil},
+		{"9223372036854775806-9223372036854775807", nil},
+		{"0-99999999999999999999", nil},
+	}
+
+	for _, test := range tests {
+		if got := parseRange(test.s); !slices.Equal(got, test.want) {
+			t.Errorf("parseRange(%q) = %v, want %v", test.s, got, test.want)
+		}
+	}
+}
+
+func rangeInts(from, to int) []int {
+	var ints []int
+	for i := from; i <= to; i++ {
+		ints = append(ints, i)
+	}
+	return ints
+}
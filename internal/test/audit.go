@@ -0,0 +1,65 @@
+package test
+
+import (
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/eduard256/strix/internal/app"
+	"github.com/eduard256/strix/pkg/tester"
+	"github.com/rs/zerolog"
+)
+
+// audit -- one JSON line per test session in STRIX_AUDIT_LOG file, for shared deployments.
+// Records who tested which hosts and how many streams were found, never URLs or credentials.
+// There is no HTTP endpoint to read it: API has no authentication, and the log of who
+// scanned what must not be public. Read the file on the host.
+var audit = zerolog.Nop()
+
+func initAudit() {
+	path := app.Env("STRIX_AUDIT_LOG", "")
+	if path == "" {
+		return
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Error().Err(err).Str("path", path).Msg("[test] audit log open")
+		return
+	}
+
+	audit = zerolog.New(f).With().Timestamp().Logger()
+}
+
+// auditSession writes session record after it is done
+func auditSession(remote string, s *tester.Session, urls []string, start time.Time) {
+	s.Lock()
+	defer s.Unlock()
+
+	audit.Log().
+		Str("session_id", s.ID).
+		Str("remote", remote).
+		Str("mode", s.Mode).
+		Strs("hosts", urlHosts(urls)).
+		Int("total", s.Total).
+		Int("tested", s.Tested).
+		Int("alive", s.Alive).
+		Dur("duration", time.Since(start)).
+		Send()
+}
+
+// urlHosts returns unique hosts without credentials and ports
+func urlHosts(urls []string) []string {
+	var hosts []string
+	for _, rawURL := range urls {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		if !slices.Contains(hosts, u.Hostname()) {
+			hosts = append(hosts, u.Hostname())
+		}
+	}
+	return hosts
+}
@@ -1,11 +1,16 @@
 package test
 
 import (
+	"cmp"
 	"crypto/rand"
+	"encoding/csv"
 	"encoding/hex"
 	"encoding/json"
 	"net/http"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,8 +25,30 @@ var log zerolog.Logger
 var sessions = map[string]*tester.Session{}
 var sessionsMu sync.Mutex
 
+// ffmpegVersion looks up ffmpeg binary, replaced in tests
+var ffmpegVersion = tester.FFmpegVersion
+
+// maxDuration -- hard limit for one test session, STRIX_MAX_TEST_DURATION
+var maxDuration = 30 * time.Minute
+
 func Init() {
-	log = app.GetLogger("test")
+	Configure()
+
+	if s := app.Env("STRIX_MAX_TEST_DURATION", ""); s != "" {
+		if d, err := time.ParseDuration(s); err == nil && d > 0 {
+			maxDuration = d
+		} else {
+			log.Warn().Str("value", s).Msg("[test] invalid STRIX_MAX_TEST_DURATION, using default")
+		}
+	}
+
+	initAudit()
+
+	if v := ffmpegVersion(); v != "" {
+		app.SetInfo("ffmpeg", v)
+	} else {
+		log.Warn().Msg("[test] ffmpeg not found, no screenshots for H264/H265 streams")
+	}
 
 	api.HandleFunc("api/test", apiTest)
 	api.HandleFunc("api/test/screenshot", apiScreenshot)
@@ -45,6 +72,69 @@ func Init() {
 	}()
 }
 
+// Configure applies tester settings from env, shared by web server and "strix test" CLI
+func Configure() {
+	log = app.GetLogger("test")
+
+	if s := app.Env("STRIX_HTTP_TIMEOUT", ""); s != "" {
+		if d, err := time.ParseDuration(s); err == nil && d > 0 {
+			tester.HTTPTimeout = d
+		} else {
+			log.Warn().Str("value", s).Msg("[test] invalid STRIX_HTTP_TIMEOUT, using default")
+		}
+	}
+
+	if s := app.Env("STRIX_HTTP_MAX_SNAPSHOT_MB", ""); s != "" {
+		if mb, err := strconv.Atoi(s); err == nil && mb > 0 {
+			tester.HTTPMaxSnapshot = int64(mb) << 20
+		} else {
+			log.Warn().Str("value", s).Msg("[test] invalid STRIX_HTTP_MAX_SNAPSHOT_MB, using default")
+		}
+	}
+
+	if s := app.Env("STRIX_TEST_STAGGER", ""); s != "" {
+		if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+			tester.WorkerStagger = d
+		} else {
+			log.Warn().Str("value", s).Msg("[test] invalid STRIX_TEST_STAGGER, using 0")
+		}
+	}
+
+	if s := app.Env("STRIX_RETRY_AFTER_MAX", ""); s != "" {
+		if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+			tester.RetryAfterMax = d
+		} else {
+			log.Warn().Str("value", s).Msg("[test] invalid STRIX_RETRY_AFTER_MAX, using default")
+		}
+	}
+
+	if s := app.Env("STRIX_DENY_PATHS", ""); s != "" {
+		if re, err := regexp.Compile(s); err == nil {
+			tester.DenyPaths = re
+		} else {
+			log.Error().Err(err).Msg("[test] invalid STRIX_DENY_PATHS")
+		}
+	}
+
+	if s := app.Env("STRIX_RTSP_TIMEOUT", ""); s != "" {
+		if d, err := time.ParseDuration(s); err == nil && d >= time.Second {
+			tester.SetRTSPTimeout(d)
+		} else {
+			log.Warn().Str("value", s).Msg("[test] invalid STRIX_RTSP_TIMEOUT, using default")
+		}
+	}
+
+	// ex. "image/pjpeg=jpeg,text/plain=auto"
+	if s := app.Env("STRIX_HTTP_TYPES", ""); s != "" {
+		for _, pair := range strings.Split(s, ",") {
+			ct, kind, _ := strings.Cut(strings.TrimSpace(pair), "=")
+			if err := tester.RegisterContentType(ct, kind); err != nil {
+				log.Warn().Err(err).Str("value", pair).Msg("[test] invalid STRIX_HTTP_TYPES")
+			}
+		}
+	}
+}
+
 func apiTest(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
@@ -53,7 +143,7 @@ func apiTest(w http.ResponseWriter, r *http.Request) {
 			apiTestList(w)
 			return
 		}
-		apiTestGet(w, id)
+		apiTestGet(w, r, id)
 
 	case "POST":
 		apiTestCreate(w, r)
@@ -61,7 +151,7 @@ func apiTest(w http.ResponseWriter, r *http.Request) {
 	case "DELETE":
 		id := r.URL.Query().Get("id")
 		if id == "" {
-			http.Error(w, "id required", http.StatusBadRequest)
+			api.ValidationError(w, r, api.FieldError{Field: "id", Rule: "required", Message: "id required"})
 			return
 		}
 		apiTestDelete(w, id)
@@ -100,7 +190,7 @@ func apiTestList(w http.ResponseWriter) {
 	api.ResponseJSON(w, map[string]any{"sessions": items})
 }
 
-func apiTestGet(w http.ResponseWriter, id string) {
+func apiTestGet(w http.ResponseWriter, r *http.Request, id string) {
 	sessionsMu.Lock()
 	s := sessions[id]
 	sessionsMu.Unlock()
@@ -110,9 +200,138 @@ func apiTestGet(w http.ResponseWriter, id string) {
 		return
 	}
 
-	s.Lock()
-	api.ResponseJSON(w, s)
-	s.Unlock()
+	q := r.URL.Query()
+
+	sortBy := q.Get("sort")
+	if sortBy != "" && sortResults[sortBy] == nil {
+		api.ValidationError(w, r, api.FieldError{Field: "sort", Rule: "enum", Message: "unknown sort: " + sortBy})
+		return
+	}
+
+	switch format := q.Get("format"); format {
+	case "", "json":
+		s.Lock()
+		if sortBy == "" {
+			api.ResponseJSON(w, s)
+		} else {
+			// results are in finish order, UI relies on it for incremental render
+			api.ResponseJSON(w, struct {
+				*tester.Session
+				Results []*tester.Result `json:"results"`
+			}{s, sortedResults(s.Results, sortBy)})
+		}
+		s.Unlock()
+	case "csv":
+		s.Lock()
+		results := sortedResults(s.Results, sortBy)
+		s.Unlock()
+		writeCSV(w, id, results, q.Get("creds") == "1")
+	case "ndjson":
+		streamNDJSON(w, r, s)
+	default:
+		api.ValidationError(w, r, api.FieldError{Field: "format", Rule: "enum", Message: "unknown format: " + format})
+	}
+}
+
+var sortResults = map[string]func(a, b *tester.Result) int{
+	"latency": func(a, b *tester.Result) int {
+		return cmp.Compare(a.LatencyMs, b.LatencyMs)
+	},
+	"resolution": func(a, b *tester.Result) int {
+		return cmp.Compare(b.Width*b.Height, a.Width*a.Height) // biggest first
+	},
+	"source": func(a, b *tester.Result) int {
+		return strings.Compare(a.Source, b.Source)
+	},
+}
+
+// sortedResults returns sorted copy of results, unsorted copy for empty sortBy
+func sortedResults(results []*tester.Result, sortBy string) []*tester.Result {
+	results = slices.Clone(results)
+	if fn := sortResults[sortBy]; fn != nil {
+		slices.SortStableFunc(results, fn)
+	}
+	return results
+}
+
+// writeCSV writes results as CSV file, passwords are masked unless creds is true
+func writeCSV(w http.ResponseWriter, id string, results []*tester.Result, creds bool) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="strix_`+id+`.csv"`)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"source", "codecs", "width", "height", "latency_ms"})
+
+	for _, r := range results {
+		source := r.Source
+		if !creds {
+			source = app.MaskURL(source)
+		}
+		_ = cw.Write([]string{
+			source,
+			strings.Join(r.Codecs, " "),
+			strconv.Itoa(r.Width),
+			strconv.Itoa(r.Height),
+			strconv.FormatInt(r.LatencyMs, 10),
+		})
+	}
+
+	cw.Flush()
+}
+
+// ndjsonWriteTimeout -- session may run longer than server WriteTimeout,
+// so deadline is moved forward on every write
+const ndjsonWriteTimeout = time.Minute
+
+// streamNDJSON writes results one JSON per line as they are found,
+// and session without results as the last line when it is done.
+// For clients without polling, ex. curl -N "localhost:4567/api/test?id=xxx&format=ndjson"
+func streamNDJSON(w http.ResponseWriter, r *http.Request, s *tester.Session) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+
+	enc := json.NewEncoder(w)
+	rc := http.NewResponseController(w)
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	var sent int
+
+	for {
+		_ = rc.SetWriteDeadline(time.Now().Add(ndjsonWriteTimeout))
+
+		s.Lock()
+		results := s.Results[sent:]
+		done := s.Status == "done"
+		s.Unlock()
+
+		for _, res := range results {
+			if err := enc.Encode(res); err != nil {
+				return
+			}
+		}
+		sent += len(results)
+
+		if done {
+			s.Lock()
+			_ = enc.Encode(struct {
+				*tester.Session
+				Results []*tester.Result `json:"results,omitempty"`
+			}{Session: s})
+			s.Unlock()
+			return
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
 }
 
 func apiTestCreate(w http.ResponseWriter, r *http.Request) {
@@ -120,30 +339,91 @@ func apiTestCreate(w http.ResponseWriter, r *http.Request) {
 		Sources struct {
 			Streams []string `json:"streams"`
 		} `json:"sources"`
+		Mode      string            `json:"mode"`
+		HostLimit int               `json:"host_limit"`
+		Headers   map[string]string `json:"headers"`
+		Debug     bool              `json:"debug"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		api.BodyError(w, err)
 		return
 	}
 
+	var errs []api.FieldError
+
 	if len(req.Sources.Streams) == 0 {
-		http.Error(w, "sources.streams required", http.StatusBadRequest)
+		errs = append(errs, api.FieldError{Field: "sources.streams", Rule: "required", Message: "sources.streams required"})
+	}
+
+	if req.Mode != "" && req.Mode != tester.ModeQuick {
+		errs = append(errs, api.FieldError{Field: "mode", Rule: "enum", Message: "unknown mode: " + req.Mode})
+	}
+
+	if req.HostLimit < 0 {
+		errs = append(errs, api.FieldError{Field: "host_limit", Rule: "min", Message: "host_limit must be positive"})
+	}
+
+	if errs != nil {
+		api.ValidationError(w, r, errs...)
 		return
 	}
 
 	id := randID()
 	s := tester.NewSession(id, len(req.Sources.Streams))
+	s.Mode = req.Mode
+	s.HostLimit = req.HostLimit
+
+	if s.Mode != tester.ModeQuick && ffmpegVersion() == "" {
+		s.Warnings = append(s.Warnings, "ffmpeg not found: streams are tested, but H264/H265 screenshots are not available")
+	}
+
+	if req.Debug {
+		s.EnableDebug(app.MaskURL)
+	}
+
+	if len(req.Headers) > 0 {
+		s.Headers = http.Header{}
+		for k, v := range req.Headers {
+			s.Headers.Set(k, v)
+		}
+	}
 
 	sessionsMu.Lock()
 	sessions[id] = s
 	sessionsMu.Unlock()
 
-	log.Debug().Str("id", id).Int("urls", len(req.Sources.Streams)).Msg("[test] session created")
-
-	go tester.RunWorkers(s, req.Sources.Streams)
-
-	api.ResponseJSON(w, map[string]string{"session_id": id})
+	// header values are not logged, may contain cookies or tokens
+	log.Debug().Str("id", id).Int("urls", len(req.Sources.Streams)).Str("mode", req.Mode).
+		Int("headers", len(req.Headers)).Bool("debug", req.Debug).Msg("[test] session created")
+
+	go func(remote string, start time.Time) {
+		timer := time.AfterFunc(maxDuration, s.Truncate)
+		tester.RunWorkers(s, req.Sources.Streams)
+		timer.Stop()
+		auditSession(remote, s, req.Sources.Streams, start)
+	}(r.RemoteAddr, time.Now())
+
+	// effective options after defaults, header values are secrets
+	headers := make([]string, 0, len(s.Headers))
+	for k := range s.Headers {
+		headers = append(headers, k)
+	}
+	slices.Sort(headers)
+
+	api.ResponseJSON(w, map[string]any{
+		"session_id": id,
+		"options": map[string]any{
+			"mode":         cmp.Or(s.Mode, "full"),
+			"host_limit":   s.HostLimit,
+			"streams":      s.Total,
+			"headers":      headers,
+			"debug":        req.Debug,
+			"http_timeout": tester.HTTPTimeout.String(),
+			"rtsp_timeout": tester.RTSPTimeout.String(),
+			"max_duration": maxDuration.String(),
+		},
+	})
 }
 
 func apiTestDelete(w http.ResponseWriter, id string) {
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+
 	"github.com/eduard256/strix/internal/api"
 	"github.com/eduard256/strix/internal/app"
 	"github.com/eduard256/strix/internal/frigate"
@@ -18,6 +22,29 @@ import (
 var version = "dev"
 
 func main() {
+	flag.Usage = usage
+	printVersion := flag.Bool("version", false, "print version and exit")
+	flag.Parse()
+
+	if *printVersion {
+		fmt.Println(version)
+		return
+	}
+
+	if args := flag.Args(); len(args) > 0 {
+		switch args[0] {
+		case "test":
+			os.Exit(cliTest(args[1:], os.Stdout))
+		case "batch":
+			os.Exit(cliBatch(args[1:], os.Stdout))
+		default:
+			fmt.Fprintf(os.Stderr, "strix: unknown command %q\n", args[0])
+			usage()
+			os.Exit(2)
+		}
+		return
+	}
+
 	app.Version = version
 
 	type module struct {
@@ -41,5 +68,7 @@ func main() {
 		m.init()
 	}
 
+	api.SetReady()
+
 	select {}
 }
@@ -1,6 +1,7 @@
 package camdb
 
 import (
+	"context"
 	"database/sql"
 	"strings"
 )
@@ -12,10 +13,10 @@ type Result struct {
 }
 
 // SearchAll returns all presets + all brands, no models
-func SearchAll(db *sql.DB) ([]Result, error) {
+func SearchAll(ctx context.Context, db *sql.DB) ([]Result, error) {
 	var results []Result
 
-	rows, err := db.Query("SELECT preset_id, name FROM presets ORDER BY preset_id")
+	rows, err := db.QueryContext(ctx, "SELECT preset_id, name FROM presets ORDER BY preset_id")
 	if err != nil {
 		return nil, err
 	}
@@ -29,7 +30,7 @@ func SearchAll(db *sql.DB) ([]Result, error) {
 		results = append(results, Result{Type: "preset", ID: "p:" + id, Name: name})
 	}
 
-	rows, err = db.Query("SELECT brand_id, brand FROM brands ORDER BY brand LIMIT ?", 50-len(results))
+	rows, err = db.QueryContext(ctx, "SELECT brand_id, brand FROM brands ORDER BY brand LIMIT ?", 50-len(results))
 	if err != nil {
 		return nil, err
 	}
@@ -48,12 +49,12 @@ func SearchAll(db *sql.DB) ([]Result, error) {
 
 // SearchQuery searches presets, brands, models by query string (limit 50 total).
 // Supports: "model", "brand model", "model brand" -- each word matches independently.
-func SearchQuery(db *sql.DB, q string) ([]Result, error) {
+func SearchQuery(ctx context.Context, db *sql.DB, q string) ([]Result, error) {
 	var results []Result
 	like := "%" + q + "%"
 
 	// presets
-	rows, err := db.Query(
+	rows, err := db.QueryContext(ctx,
 		"SELECT preset_id, name FROM presets WHERE preset_id LIKE ? OR name LIKE ? ORDER BY preset_id",
 		like, like,
 	)
@@ -71,7 +72,7 @@ func SearchQuery(db *sql.DB, q string) ([]Result, error) {
 	}
 
 	// brands
-	rows, err = db.Query(
+	rows, err = db.QueryContext(ctx,
 		"SELECT brand_id, brand FROM brands WHERE brand_id LIKE ? OR brand LIKE ? ORDER BY brand LIMIT ?",
 		like, like, 50-len(results),
 	)
@@ -92,21 +93,30 @@ func SearchQuery(db *sql.DB, q string) ([]Result, error) {
 		return results, nil
 	}
 
-	// models -- each word must match brand or model
+	// models -- each word must match brand or model,
+	// model also matches with separators stripped on both sides
 	words := strings.Fields(q)
 	where := ""
-	args := make([]any, 0, len(words)+1)
-	for i, w := range words {
-		if i > 0 {
+	args := make([]any, 0, 4*len(words)+1)
+	for _, w := range words {
+		// separators only, ex. "-", would match every model
+		n := normalizeModel(w)
+		if n == "" {
+			continue
+		}
+		if where != "" {
 			where += " AND "
 		}
-		where += "(b.brand LIKE ? OR b.brand_id LIKE ? OR sm.model LIKE ?)"
+		where += "(b.brand LIKE ? OR b.brand_id LIKE ? OR sm.model LIKE ? OR " + sqlNormalize("sm.model") + " LIKE ?)"
 		p := "%" + w + "%"
-		args = append(args, p, p, p)
+		args = append(args, p, p, p, "%"+n+"%")
+	}
+	if where == "" {
+		return results, nil
 	}
 	args = append(args, 50-len(results))
 
-	rows, err = db.Query(
+	rows, err = db.QueryContext(ctx,
 		`SELECT DISTINCT b.brand_id, b.brand, sm.model
 		FROM stream_models sm
 		JOIN streams s ON s.id = sm.stream_id
@@ -135,3 +145,25 @@ func SearchQuery(db *sql.DB, q string) ([]Result, error) {
 
 	return results, nil
 }
+
+// internals
+
+// modelSeparators are ignored when comparing models,
+// so "ds2cd2042", "ds-2cd-2042" and "DS 2CD2042WD" all match "DS-2CD2042WD"
+var modelSeparators = []string{"-", " ", "_", ".", "/"}
+
+// normalizeModel removes separators from model query word
+func normalizeModel(s string) string {
+	for _, sep := range modelSeparators {
+		s = strings.ReplaceAll(s, sep, "")
+	}
+	return s
+}
+
+// sqlNormalize wraps SQL column with REPLACE calls, same as normalizeModel
+func sqlNormalize(column string) string {
+	for _, sep := range modelSeparators {
+		column = "REPLACE(" + column + ", '" + sep + "', '')"
+	}
+	return column
+}
@@ -0,0 +1,62 @@
+package camdb
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestSearchQueryModel(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, query := range []string{
+		"CREATE TABLE presets (preset_id TEXT, name TEXT)",
+		"CREATE TABLE brands (brand_id TEXT, brand TEXT)",
+		"CREATE TABLE streams (id INTEGER, brand_id TEXT)",
+		"CREATE TABLE stream_models (stream_id INTEGER, model TEXT)",
+		"INSERT INTO brands VALUES ('hikvision', 'Hikvision'), ('dahua', 'Dahua')",
+		"INSERT INTO streams VALUES (1, 'hikvision'), (2, 'dahua')",
+		"INSERT INTO stream_models VALUES (1, 'DS-2CD2042WD'), (1, 'DS-2CD2142F'), (2, 'IPC-HDW1230S')",
+	} {
+		if _, err = db.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"ds2cd2042", []string{"Hikvision: DS-2CD2042WD"}},
+		{"ds-2cd-2042", []string{"Hikvision: DS-2CD2042WD"}},
+		{"DS 2CD2042WD", []string{"Hikvision: DS-2CD2042WD"}},
+		{"hikvision 2cd2142", []string{"Hikvision: DS-2CD2142F"}},
+		{"ipc_hdw", []string{"Dahua: IPC-HDW1230S"}},
+		{"-", nil},
+		{"ds2cd2042 /", []string{"Hikvision: DS-2CD2042WD"}},
+	}
+
+	for _, test := range tests {
+		results, err := SearchQuery(context.Background(), db, test.query)
+		if err != nil {
+			t.Errorf("%q: %v", test.query, err)
+			continue
+		}
+		var got []string
+		for _, r := range results {
+			if r.Type == "model" {
+				got = append(got, r.Name)
+			}
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%q: got %v, want %v", test.query, got, test.want)
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package camdb
 
 import (
+	"context"
 	"database/sql"
 	"encoding/base64"
 	"fmt"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -27,15 +29,38 @@ type StreamParams struct {
 	Pass    string
 	Channel int
 	Ports   map[int]bool // nil = no filter
+	Limit   int          // max URLs, 0 = MaxStreams
+
+	// Protocols filter, ex. {"rtsp": true} for RTSP-only consumers, nil = no filter
+	Protocols map[string]bool
+
+	// Channels replaces Channel with range, also rewrites literal "channel=1" in query (channel+1)
+	Channels []int
+	// Subtypes rewrites literal "subtype=0" in query, ex. Dahua main and sub stream
+	Subtypes []int
+	// Width and Height for [WIDTH] and [HEIGHT] placeholders, 0 = DefaultWidth, DefaultHeight
+	Width, Height int
+	// Anon adds RTSP URL without credentials after each URL with credentials,
+	// for cameras with open RTSP that reject wrong credentials
+	Anon bool
 }
 
+// MaxStreams is upper limit of URLs per BuildStreams call
+const MaxStreams = 20000
+
+// DefaultWidth and DefaultHeight are used for resolution placeholders when request has none
+var DefaultWidth, DefaultHeight = 640, 480
+
 type raw struct {
 	url, protocol string
 	port          int
 }
 
-// BuildStreams resolves IDs to full stream URLs with credentials and placeholders substituted
-func BuildStreams(db *sql.DB, p *StreamParams) ([]string, error) {
+// BuildStreams resolves IDs to full stream URLs with credentials and placeholders substituted.
+// Queries are cancelled with ctx, ex. when HTTP client disconnects.
+// URLs keep IDs order, so with limit the first IDs have priority.
+// Returns truncated = true if some URLs were dropped because of limit.
+func BuildStreams(ctx context.Context, db *sql.DB, p *StreamParams) (streams []string, truncated bool, err error) {
 	var raws []raw
 
 	for _, id := range strings.Split(p.IDs, ",") {
@@ -44,98 +69,200 @@ func BuildStreams(db *sql.DB, p *StreamParams) ([]string, error) {
 			continue
 		}
 
-		var rows *sql.Rows
-		var err error
-
-		switch {
-		case strings.HasPrefix(id, "b:"):
-			brandID := id[2:]
-			rows, err = db.Query(
-				"SELECT url, protocol, port FROM streams WHERE brand_id = ?", brandID,
-			)
-
-		case strings.HasPrefix(id, "m:"):
-			parts := strings.SplitN(id[2:], ":", 2)
-			if len(parts) != 2 {
-				return nil, fmt.Errorf("camdb: invalid model id: %s", id)
-			}
-			rows, err = db.Query(
-				`SELECT s.url, s.protocol, s.port
-				FROM stream_models sm
-				JOIN streams s ON s.id = sm.stream_id
-				WHERE s.brand_id = ? AND sm.model = ?`,
-				parts[0], parts[1],
-			)
-
-		case strings.HasPrefix(id, "p:"):
-			presetID := id[2:]
-			rows, err = db.Query(
-				"SELECT url, protocol, port FROM preset_streams WHERE preset_id = ?", presetID,
-			)
-
-		default:
-			return nil, fmt.Errorf("camdb: unknown id prefix: %s", id)
-		}
-
+		r, err := loadPatterns(ctx, db, id)
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
+		raws = append(raws, r...)
+	}
 
-		found := false
-		for rows.Next() {
-			var r raw
-			if err = rows.Scan(&r.url, &r.protocol, &r.port); err != nil {
-				rows.Close()
-				return nil, err
-			}
-			raws = append(raws, r)
-			found = true
-		}
-		rows.Close()
+	width, height := p.Width, p.Height
+	if width <= 0 || height <= 0 {
+		width, height = DefaultWidth, DefaultHeight
+	}
 
-		if !found {
-			return nil, fmt.Errorf("camdb: not found: %s", id)
-		}
+	limit := p.Limit
+	if limit <= 0 || limit > MaxStreams {
+		limit = MaxStreams
 	}
 
 	// build full URLs, deduplicate
 	seen := map[string]bool{}
-	var streams []string
 
 	for _, r := range raws {
-		if len(streams) >= 20000 {
-			break
+		if p.Protocols != nil && !p.Protocols[r.protocol] {
+			continue
 		}
 
-		port := r.port
-		if port == 0 {
-			if p, ok := defaultPorts[r.protocol]; ok {
-				port = p
-			} else {
-				port = 80
-			}
-		}
+		port := r.defaultPort()
 
 		if p.Ports != nil && !p.Ports[port] {
 			continue
 		}
 
-		u := buildURL(r.protocol, r.url, p.IP, port, p.User, p.Pass, p.Channel)
-		if seen[u] {
-			continue
+		anon := p.Anon && p.User != "" && (r.protocol == "rtsp" || r.protocol == "rtsps")
+
+		for _, v := range expandPath(r.url, p) {
+			urls := []string{buildURL(r.protocol, v.path, p.IP, port, p.User, p.Pass, v.channel, width, height)}
+			if anon {
+				urls = append(urls, buildURL(r.protocol, v.path, p.IP, port, "", "", v.channel, width, height))
+			}
+
+			for _, u := range urls {
+				if seen[u] {
+					continue
+				}
+				if len(streams) >= limit {
+					return streams, true, nil
+				}
+				seen[u] = true
+				streams = append(streams, u)
+			}
 		}
-		seen[u] = true
-		streams = append(streams, u)
 	}
 
-	return streams, nil
+	return streams, truncated, nil
+}
+
+// Pattern is database URL pattern before placeholders substitution
+type Pattern struct {
+	URL      string `json:"url"`
+	Protocol string `json:"protocol"`
+	Port     int    `json:"port,omitempty"`
+	Example  string `json:"example"` // URL built with sample IP and credentials
 }
 
+// Patterns returns URL patterns for one ID, same as BuildStreams would use.
+// ex. "m:hikvision:DS-2CD2032-I"
+func Patterns(ctx context.Context, db *sql.DB, id string) ([]Pattern, error) {
+	raws, err := loadPatterns(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := make([]Pattern, 0, len(raws))
+	for _, r := range raws {
+		patterns = append(patterns, Pattern{
+			URL:      r.url,
+			Protocol: r.protocol,
+			Port:     r.port,
+			Example:  buildURL(r.protocol, r.url, "192.168.1.100", r.defaultPort(), "admin", "password", 0, DefaultWidth, DefaultHeight),
+		})
+	}
+	return patterns, nil
+}
 
 // internals
 
-func buildURL(protocol, path, ip string, port int, user, pass string, channel int) string {
-	path = replacePlaceholders(path, ip, port, user, pass, channel)
+// loadPatterns queries raw patterns for brand "b:", model "m:" or preset "p:" ID
+func loadPatterns(ctx context.Context, db *sql.DB, id string) ([]raw, error) {
+	var rows *sql.Rows
+	var err error
+
+	switch {
+	case strings.HasPrefix(id, "b:"):
+		brandID := id[2:]
+		rows, err = db.QueryContext(ctx,
+			"SELECT url, protocol, port FROM streams WHERE brand_id = ?", brandID,
+		)
+
+	case strings.HasPrefix(id, "m:"):
+		parts := strings.SplitN(id[2:], ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("camdb: invalid model id: %s", id)
+		}
+		rows, err = db.QueryContext(ctx,
+			`SELECT s.url, s.protocol, s.port
+			FROM stream_models sm
+			JOIN streams s ON s.id = sm.stream_id
+			WHERE s.brand_id = ? AND sm.model = ?`,
+			parts[0], parts[1],
+		)
+
+	case strings.HasPrefix(id, "p:"):
+		presetID := id[2:]
+		rows, err = db.QueryContext(ctx,
+			"SELECT url, protocol, port FROM preset_streams WHERE preset_id = ?", presetID,
+		)
+
+	default:
+		return nil, fmt.Errorf("camdb: unknown id prefix: %s", id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var raws []raw
+	for rows.Next() {
+		var r raw
+		if err = rows.Scan(&r.url, &r.protocol, &r.port); err != nil {
+			return nil, err
+		}
+		raws = append(raws, r)
+	}
+
+	if len(raws) == 0 {
+		return nil, fmt.Errorf("camdb: not found: %s", id)
+	}
+	return raws, nil
+}
+
+// defaultPort returns pattern port or default port for protocol
+func (r raw) defaultPort() int {
+	if r.port != 0 {
+		return r.port
+	}
+	if p, ok := defaultPorts[r.protocol]; ok {
+		return p
+	}
+	return 80
+}
+
+type variant struct {
+	path    string
+	channel int
+}
+
+var (
+	reChannel = regexp.MustCompile(`(?i)([?&]channel=)\d+`)
+	reSubtype = regexp.MustCompile(`(?i)([?&]subtype=)\d+`)
+)
+
+// expandPath returns path for every channel and subtype from params,
+// ex. "/cam/realmonitor?channel=1&subtype=0" with channels 0-1, subtypes 0-1 -> 4 paths
+func expandPath(path string, p *StreamParams) []variant {
+	if p.Channels == nil && p.Subtypes == nil {
+		return []variant{{path, p.Channel}}
+	}
+
+	channels := p.Channels
+	if channels == nil {
+		channels = []int{p.Channel}
+	}
+
+	var variants []variant
+	for _, ch := range channels {
+		s := path
+		if p.Channels != nil {
+			s = reChannel.ReplaceAllString(s, "${1}"+strconv.Itoa(ch+1))
+		}
+
+		if p.Subtypes == nil || !reSubtype.MatchString(s) {
+			variants = append(variants, variant{s, ch})
+			continue
+		}
+
+		for _, st := range p.Subtypes {
+			variants = append(variants, variant{reSubtype.ReplaceAllString(s, "${1}"+strconv.Itoa(st)), ch})
+		}
+	}
+	return variants
+}
+
+func buildURL(protocol, path, ip string, port int, user, pass string, channel, width, height int) string {
+	path = replacePlaceholders(path, ip, port, user, pass, channel, width, height)
 
 	var auth string
 	if user != "" {
@@ -154,7 +281,7 @@ func buildURL(protocol, path, ip string, port int, user, pass string, channel in
 	return protocol + "://" + auth + host + path
 }
 
-func replacePlaceholders(s, ip string, port int, user, pass string, channel int) string {
+func replacePlaceholders(s, ip string, port int, user, pass string, channel, width, height int) string {
 	auth := ""
 	if user != "" && pass != "" {
 		auth = base64.StdEncoding.EncodeToString([]byte(user + ":" + pass))
@@ -179,8 +306,8 @@ func replacePlaceholders(s, ip string, port int, user, pass string, channel int)
 		"[PASWORD]", encPass, "[pasword]", encPass,
 		"[PASS]", encPass, "[pass]", encPass,
 		"[PWD]", encPass, "[pwd]", encPass,
-		"[WIDTH]", "640", "[width]", "640",
-		"[HEIGHT]", "480", "[height]", "480",
+		"[WIDTH]", strconv.Itoa(width), "[width]", strconv.Itoa(width),
+		"[HEIGHT]", strconv.Itoa(height), "[height]", strconv.Itoa(height),
 		"[IP]", ip, "[ip]", ip,
 		"[PORT]", strconv.Itoa(port), "[port]", strconv.Itoa(port),
 		"[AUTH]", auth, "[auth]", auth,
@@ -0,0 +1,56 @@
+package camdb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandPath(t *testing.T) {
+	tests := []struct {
+		path string
+		p    StreamParams
+		want []variant
+	}{
+		{
+			"/cam/realmonitor?channel=1&subtype=0",
+			StreamParams{Channel: 2},
+			[]variant{{"/cam/realmonitor?channel=1&subtype=0", 2}},
+		},
+		{
+			"/cam/realmonitor?channel=1&subtype=0",
+			StreamParams{Channels: []int{0, 1}, Subtypes: []int{0, 1}},
+			[]variant{
+				{"/cam/realmonitor?channel=1&subtype=0", 0},
+				{"/cam/realmonitor?channel=1&subtype=1", 0},
+				{"/cam/realmonitor?channel=2&subtype=0", 1},
+				{"/cam/realmonitor?channel=2&subtype=1", 1},
+			},
+		},
+		{
+			"/cam/realmonitor?Channel=1&subtype=0",
+			StreamParams{Channel: 3, Subtypes: []int{1}},
+			[]variant{{"/cam/realmonitor?Channel=1&subtype=1", 3}},
+		},
+		{
+			// no subtype in query, one path per channel
+			"/Streaming/Channels/[CHANNEL+1]01",
+			StreamParams{Channels: []int{0, 2}, Subtypes: []int{0, 1}},
+			[]variant{
+				{"/Streaming/Channels/[CHANNEL+1]01", 0},
+				{"/Streaming/Channels/[CHANNEL+1]01", 2},
+			},
+		},
+		{
+			// "channel" inside other param name is not replaced
+			"/live?subchannel=1&channel=1",
+			StreamParams{Channels: []int{4}},
+			[]variant{{"/live?subchannel=1&channel=5", 4}},
+		},
+	}
+
+	for _, test := range tests {
+		if got := expandPath(test.path, &test.p); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("expandPath(%q, %+v) = %v, want %v", test.path, test.p, got, test.want)
+		}
+	}
+}
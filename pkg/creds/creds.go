@@ -0,0 +1,103 @@
+package creds
+
+import (
+	"errors"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// UserinfoEnd returns index of "@" that ends credentials in URL without scheme,
+// -1 if there are none. Password may contain "@", "#" and even "/", path may contain "@" too.
+// The last "@" before any "/" with valid host after it wins, then the first "@"
+// with valid host, ex. "admin:p@ss@192.168.1.100/snap@1.jpg".
+// "@" after "/" is never credentials when URL starts with address, ex. "192.168.1.100/snap@1.jpg"
+// Shared by URL normalization and log masking, www/test.html has JS copy of it.
+func UserinfoEnd(s string) int {
+	slashed, broken := -1, -1
+	slashOK := !addressHost(hostPart(s))
+
+	for i := strings.LastIndexByte(s, '@'); i >= 0; i = strings.LastIndexByte(s[:i], '@') {
+		slash := strings.Contains(s[:i], "/")
+		switch {
+		case CheckHost(hostPart(s[i+1:])) == nil:
+			if !slash {
+				return i
+			}
+			if slashOK {
+				slashed = i
+			}
+		case !slash && broken < 0:
+			broken = i // credentials with broken host, CheckHost will explain
+		}
+	}
+
+	if slashed >= 0 {
+		return slashed
+	}
+	return broken
+}
+
+// CheckHost validates host with optional port, ex. "192.168.1.100:554", "[::1]", "camera.local"
+func CheckHost(hostport string) error {
+	if hostport == "" {
+		return errors.New("no host")
+	}
+
+	host, port := hostport, ""
+	if h, p, err := net.SplitHostPort(hostport); err == nil {
+		host, port = h, p
+	} else if strings.Count(hostport, ":") == 1 {
+		return errors.New("invalid port: " + hostport)
+	}
+
+	if port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+			return errors.New("invalid port: " + port)
+		}
+	}
+
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	for _, c := range host {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.') {
+			return errors.New("invalid host: " + host)
+		}
+	}
+	return nil
+}
+
+// UserParams and PasswordParams -- query keys with credentials,
+// ex. "/snapshot.cgi?user=admin&pwd=pass" or Foscam "?loginuse=admin&loginpas=pass"
+var (
+	UserParams     = []string{"user", "username", "usr", "account", "loginuse"}
+	PasswordParams = []string{"pass", "password", "passwd", "pwd", "loginpas", "pin"}
+)
+
+// IsParam checks query key is user or password, case-insensitive
+func IsParam(key string) bool {
+	key = strings.ToLower(key)
+	return slices.Contains(UserParams, key) || slices.Contains(PasswordParams, key)
+}
+
+func hostPart(s string) string {
+	if i := strings.IndexAny(s, "/?#"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// addressHost checks that hostport is a real address, not "user:pass" with valid
+// chars, ex. "192.168.1.100:554", "[::1]", "camera.local", but not "admin:1234"
+func addressHost(hostport string) bool {
+	if strings.Contains(hostport, "@") || CheckHost(hostport) != nil {
+		return false
+	}
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	return net.ParseIP(host) != nil || strings.Contains(host, ".") || host == "localhost"
+}
@@ -0,0 +1,43 @@
+package creds
+
+import "testing"
+
+func TestUserinfoEnd(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"admin:pass@192.168.1.100/live", 10},
+		{"admin@192.168.1.100/live", 5},
+		{"192.168.1.100/live", -1},
+		// special chars in raw password
+		{"admin:p@ss@192.168.1.100/snap@1.jpg", 10},
+		{"admin:pa#ss@1.2.3.4/x", 11},
+		{"admin:p/ss@1.2.3.4/x", 10},
+		{"admin:1234/x@1.2.3.4/live", 12},
+		// "@" in path or query is not credentials
+		{"192.168.1.100/snap@1.jpg", -1},
+		{"10.0.0.5:554/live/token@abc", -1},
+		{"camera.local/snap?email=a@b.c", -1},
+		// broken host after credentials
+		{"admin:pass@/live", 10},
+	}
+	for _, test := range tests {
+		if got := UserinfoEnd(test.s); got != test.want {
+			t.Errorf("%q: got %d, want %d", test.s, got, test.want)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	for _, host := range []string{"192.168.1.100", "192.168.1.100:554", "[fe80::1]:554", "camera.local", "nvr-1"} {
+		if err := CheckHost(host); err != nil {
+			t.Errorf("%q: %v", host, err)
+		}
+	}
+	for _, host := range []string{"", "192.168.1.100:99999", "admin:abc", "cam_1.local", "a b"} {
+		if err := CheckHost(host); err == nil {
+			t.Errorf("%q: want error", host)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package generate
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+)
+
+// HARequest -- Home Assistant generic camera from tested stream and snapshot URLs
+type HARequest struct {
+	MainStream    string `json:"mainStream"`
+	Snapshot      string `json:"snapshot,omitempty"`
+	Name          string `json:"name,omitempty"`
+	RTSPTransport string `json:"-"` // transport stream was tested with, set by API, see tester.RTSPTransport
+}
+
+var haTransports = map[string]bool{"tcp": true, "udp": true, "udp_multicast": true, "http": true}
+
+// HomeAssistant generates values for Generic Camera integration form.
+// YAML "camera: - platform: generic" is deprecated and rejected by current
+// Home Assistant, so keys are named as form fields, one value per line.
+func HomeAssistant(req *HARequest) (*Response, error) {
+	if req.MainStream == "" && req.Snapshot == "" {
+		return nil, fmt.Errorf("generate: mainStream or snapshot required")
+	}
+
+	transport := req.RTSPTransport
+	if transport != "" && !haTransports[transport] {
+		return nil, fmt.Errorf("generate: unknown rtsp transport: %s", transport)
+	}
+
+	name := req.Name
+	if name == "" {
+		name = "camera"
+		if ip := extractIP(cmp.Or(req.MainStream, req.Snapshot)); ip != "" {
+			name = "camera_" + strings.NewReplacer(".", "_", ":", "_").Replace(ip)
+		}
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n", name)
+	b.WriteString("# Home Assistant > Settings > Devices & services > Add integration > Generic Camera\n")
+	if req.Snapshot != "" {
+		fmt.Fprintf(&b, "still_image_url: %s\n", yamlQuote(req.Snapshot))
+	}
+	if req.MainStream != "" {
+		fmt.Fprintf(&b, "stream_source: %s\n", yamlQuote(req.MainStream))
+		if transport != "" && strings.HasPrefix(req.MainStream, "rtsp") {
+			fmt.Fprintf(&b, "rtsp_transport: %s\n", transport)
+		}
+	}
+
+	config := b.String()
+	added := make([]int, strings.Count(config, "\n"))
+	for i := range added {
+		added[i] = i + 1
+	}
+
+	return &Response{Config: config, Added: added}, nil
+}
+
+// yamlQuote -- single quoted YAML string, URLs may contain ": " or " #"
+func yamlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,133 @@
+package probe
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// brandEvidence -- how much each probe tells about brand, ONVIF answers with
+// manufacturer, OUI may be a chip or module vendor, realm and banner are often generic
+var brandEvidence = []struct {
+	source string
+	weight float64
+	text   func(r *Response) string
+}{
+	{"onvif", 0.6, func(r *Response) string {
+		if o := r.Probes.ONVIF; o != nil {
+			return o.Name + " " + o.Hardware
+		}
+		return ""
+	}},
+	{"arp", 0.4, func(r *Response) string {
+		if a := r.Probes.ARP; a != nil {
+			return a.Vendor
+		}
+		return ""
+	}},
+	{"mdns", 0.4, func(r *Response) string {
+		if m := r.Probes.MDNS; m != nil {
+			return m.Name + " " + m.Model
+		}
+		return ""
+	}},
+	{"http", 0.3, func(r *Response) string {
+		if h := r.Probes.HTTP; h != nil {
+			return h.Server + " " + h.Realm
+		}
+		return ""
+	}},
+	{"dns", 0.2, func(r *Response) string {
+		if d := r.Probes.DNS; d != nil {
+			return d.Hostname
+		}
+		return ""
+	}},
+}
+
+// GuessBrand matches database brand names against probe answers, without testing streams.
+// Confidence is sum of weights of agreeing probes, max 1. Returns nil if nothing matched.
+// ex. ARP "Hangzhou Hikvision Digital Technology" + HTTP "Hikvision-Webs" -> "b:hikvision", 0.7
+func GuessBrand(ctx context.Context, db *sql.DB, r *Response) (*BrandResult, error) {
+	texts := make([][]string, len(brandEvidence))
+	var empty = true
+	for i, e := range brandEvidence {
+		if texts[i] = words(e.text(r)); texts[i] != nil {
+			empty = false
+		}
+	}
+	if empty {
+		return nil, nil
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT brand_id, brand FROM brands")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var best *BrandResult
+
+	for rows.Next() {
+		var id, name string
+		if err = rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+
+		brand := words(name)
+		// short names like "IP" or "TP" match everything
+		if len(strings.Join(brand, "")) < 3 {
+			continue
+		}
+
+		var guess *BrandResult
+		for i, e := range brandEvidence {
+			if !containsWords(texts[i], brand) {
+				continue
+			}
+			if guess == nil {
+				guess = &BrandResult{ID: "b:" + id, Name: name}
+			}
+			guess.Confidence += e.weight
+			guess.Sources = append(guess.Sources, e.source)
+		}
+
+		// same score -- longer name is more specific, ex. "TP-Link Tapo" over "TP-Link"
+		if guess != nil && (best == nil || guess.Confidence > best.Confidence ||
+			guess.Confidence == best.Confidence && len(guess.Name) > len(best.Name)) {
+			best = guess
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if best != nil {
+		best.Confidence = min(1, float64(int(best.Confidence*100+0.5))/100)
+	}
+	return best, nil
+}
+
+// internals
+
+// words splits text to lowercase letters and digits, ex. "Hikvision-Webs" -> ["hikvision", "webs"]
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// containsWords checks that sub is a sequence of consecutive words in text
+func containsWords(text, sub []string) bool {
+	if len(sub) == 0 {
+		return false
+	}
+	for i := 0; i+len(sub) <= len(text); i++ {
+		if slices.Equal(text[i:i+len(sub)], sub) {
+			return true
+		}
+	}
+	return false
+}
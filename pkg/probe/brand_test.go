@@ -0,0 +1,83 @@
+package probe
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestGuessBrand(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, query := range []string{
+		"CREATE TABLE brands (brand_id TEXT, brand TEXT)",
+		`INSERT INTO brands VALUES ('hikvision', 'Hikvision'), ('dahua', 'Dahua'),
+			('tp-link', 'TP-Link'), ('tp-link-tapo', 'TP-Link Tapo'),
+			('ip', 'IP'), ('tp', 'TP'), ('a-b', 'A-B')`,
+	} {
+		if _, err = db.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		probes Probes
+		want   *BrandResult
+	}{
+		{
+			"arp and http",
+			Probes{
+				ARP:  &ARPResult{Vendor: "Hangzhou Hikvision Digital Technology Co.,Ltd."},
+				HTTP: &HTTPResult{Server: "Hikvision-Webs"},
+			},
+			&BrandResult{ID: "b:hikvision", Name: "Hikvision", Confidence: 0.7, Sources: []string{"arp", "http"}},
+		},
+		{
+			"realm",
+			Probes{HTTP: &HTTPResult{Server: "uc-httpd", Realm: "Dahua IPC-HDW1230S"}},
+			&BrandResult{ID: "b:dahua", Name: "Dahua", Confidence: 0.3, Sources: []string{"http"}},
+		},
+		{
+			"capped confidence",
+			Probes{
+				ONVIF: &ONVIFResult{Name: "HIKVISION", Hardware: "DS-2CD2042WD"},
+				ARP:   &ARPResult{Vendor: "Hikvision"},
+				HTTP:  &HTTPResult{Server: "Hikvision-Webs"},
+				DNS:   &DNSResult{Hostname: "hikvision-cam.lan"},
+			},
+			&BrandResult{ID: "b:hikvision", Name: "Hikvision", Confidence: 1, Sources: []string{"onvif", "arp", "http", "dns"}},
+		},
+		{
+			// same score, longer name is more specific
+			"longer name",
+			Probes{MDNS: &MDNSResult{Name: "TP-Link Tapo C200", Model: "C200"}},
+			&BrandResult{ID: "b:tp-link-tapo", Name: "TP-Link Tapo", Confidence: 0.4, Sources: []string{"mdns"}},
+		},
+		{
+			// "IP", "TP" and "A-B" are shorter than 3 letters, would match any text
+			"short names",
+			Probes{HTTP: &HTTPResult{Server: "IP Camera", Realm: "TP a-b"}},
+			nil,
+		},
+		{"nothing", Probes{}, nil},
+	}
+
+	for _, test := range tests {
+		got, err := GuessBrand(context.Background(), db, &Response{Probes: test.probes})
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
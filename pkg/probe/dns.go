@@ -2,8 +2,12 @@ package probe
 
 import (
 	"context"
+	"errors"
 	"net"
 	"strings"
+	"time"
+
+	"github.com/miekg/dns"
 )
 
 func ReverseDNS(ctx context.Context, ip string) (*DNSResult, error) {
@@ -19,3 +23,76 @@ func ReverseDNS(ctx context.Context, ip string) (*DNSResult, error) {
 
 	return &DNSResult{Hostname: hostname}, nil
 }
+
+// LookupHost resolves hostname to IPv4 address. Names in ".local" zone are
+// resolved with multicast DNS, others with system resolver.
+// ex. "camera.local" -> "192.168.1.100"
+func LookupHost(ctx context.Context, host string) (string, error) {
+	host = strings.TrimSuffix(host, ".")
+
+	if strings.HasSuffix(strings.ToLower(host), ".local") {
+		return lookupMDNS(ctx, host)
+	}
+
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip4", host)
+	if err != nil {
+		return "", err
+	}
+	if len(ips) == 0 {
+		return "", errors.New("no address for " + host)
+	}
+	return ips[0].String(), nil
+}
+
+// internals
+
+func lookupMDNS(ctx context.Context, host string) (string, error) {
+	name := dns.Fqdn(host)
+
+	msg := &dns.Msg{
+		Question: []dns.Question{
+			{Name: name, Qtype: dns.TypeA, Qclass: dns.ClassINET},
+		},
+	}
+
+	query, err := msg.Pack()
+	if err != nil {
+		return "", err
+	}
+
+	conn, err := net.ListenMulticastUDP("udp4", nil, multicastAddr)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Second)
+	}
+	_ = conn.SetDeadline(deadline)
+
+	if _, err = conn.WriteTo(query, multicastAddr); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, 1500)
+
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return "", errors.New("no mdns answer for " + host)
+		}
+
+		var resp dns.Msg
+		if err = resp.Unpack(buf[:n]); err != nil {
+			continue
+		}
+
+		for _, rr := range resp.Answer {
+			if a, ok := rr.(*dns.A); ok && strings.EqualFold(a.Hdr.Name, name) {
+				return a.A.String(), nil
+			}
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"regexp"
 )
 
 func ProbeHTTP(ctx context.Context, ip string, ports []int) (*HTTPResult, error) {
@@ -57,9 +58,23 @@ func ProbeHTTP(ctx context.Context, ip string, ports []int) (*HTTPResult, error)
 				Port:       r.port,
 				StatusCode: r.resp.StatusCode,
 				Server:     r.resp.Header.Get("Server"),
+				Realm:      authRealm(r.resp.Header.Values("WWW-Authenticate")),
 			}, nil
 		}
 	}
 
 	return nil, nil
 }
+
+var reRealm = regexp.MustCompile(`(?i)realm="([^"]*)"`)
+
+// authRealm returns realm from first auth challenge,
+// ex. `Digest realm="IP Camera(C6024)", nonce="..."` -> "IP Camera(C6024)"
+func authRealm(values []string) string {
+	for _, v := range values {
+		if m := reRealm.FindStringSubmatch(v); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
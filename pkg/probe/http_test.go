@@ -0,0 +1,57 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAuthRealm(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   string
+	}{
+		{[]string{`Digest realm="IP Camera(C6024)", nonce="abc", qop="auth"`}, "IP Camera(C6024)"},
+		{[]string{`Basic realm="DS-2CD2042WD"`}, "DS-2CD2042WD"},
+		{[]string{`basic REALM="Login to 3f2a1b"`}, "Login to 3f2a1b"},
+		{[]string{`Negotiate`, `Basic realm="NVR"`}, "NVR"},
+		{[]string{`Digest nonce="abc", realm=""`}, ""},
+		{[]string{`Basic realm=unquoted`}, ""},
+		{nil, ""},
+	}
+
+	for _, test := range tests {
+		if got := authRealm(test.values); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.values, got, test.want)
+		}
+	}
+}
+
+func TestProbeHTTPRealm(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "uc-httpd 1.0.0")
+		w.Header().Set("WWW-Authenticate", `Basic realm="IPC-HDW1230S"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	res, err := ProbeHTTP(ctx, u.Hostname(), []int{port})
+	if err != nil || res == nil {
+		t.Fatalf("res = %v, err = %v", res, err)
+	}
+
+	want := HTTPResult{Port: port, StatusCode: http.StatusUnauthorized, Server: "uc-httpd 1.0.0", Realm: "IPC-HDW1230S"}
+	if *res != want {
+		t.Errorf("got %+v, want %+v", *res, want)
+	}
+}
@@ -3,6 +3,7 @@ package probe
 import (
 	"context"
 	"net"
+	"slices"
 	"strings"
 	"time"
 
@@ -77,8 +78,124 @@ func QueryHAP(ctx context.Context, ip string) (*MDNSResult, error) {
 	}
 }
 
+// BrowseServices -- DNS-SD service types announced by cameras and NVRs
+var BrowseServices = []string{
+	"_rtsp._tcp.local.",
+	"_http._tcp.local.",
+	"_onvif._tcp.local.",
+	hapService,
+}
+
+// BrowseMDNS sends multicast PTR queries for BrowseServices and collects
+// answers until ctx deadline. Devices are grouped by source IP, in answer order.
+// Optional found is called when device answers first time or with new services.
+func BrowseMDNS(ctx context.Context, found func(*MDNSDevice)) ([]*MDNSDevice, error) {
+	msg := &dns.Msg{}
+	for _, service := range BrowseServices {
+		msg.Question = append(msg.Question, dns.Question{Name: service, Qtype: dns.TypePTR, Qclass: dns.ClassINET})
+	}
+
+	query, err := msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenMulticastUDP("udp4", nil, multicastAddr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Second)
+	}
+	_ = conn.SetDeadline(deadline)
+
+	if _, err = conn.WriteTo(query, multicastAddr); err != nil {
+		return nil, err
+	}
+
+	var devices []*MDNSDevice
+	byIP := map[string]*MDNSDevice{}
+	buf := make([]byte, 9000) // mDNS allows jumbo packets
+
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return devices, nil // timeout
+		}
+
+		var resp dns.Msg
+		if err = resp.Unpack(buf[:n]); err != nil || !resp.Response {
+			continue
+		}
+
+		ip := from.(*net.UDPAddr).IP.String()
+		device := byIP[ip]
+		if device == nil {
+			device = &MDNSDevice{IP: ip}
+		}
+
+		if !parseBrowseResponse(&resp, device) {
+			continue
+		}
+
+		if byIP[ip] == nil {
+			byIP[ip] = device
+			devices = append(devices, device)
+		}
+
+		if found != nil {
+			found(device)
+		}
+	}
+}
+
 // internals
 
+// parseBrowseResponse adds services from PTR answers with SRV port and host, false if nothing new was found
+func parseBrowseResponse(msg *dns.Msg, device *MDNSDevice) bool {
+	records := make([]dns.RR, 0, len(msg.Answer)+len(msg.Extra))
+	records = append(records, msg.Answer...)
+	records = append(records, msg.Extra...)
+
+	var found bool
+
+	for _, rr := range records {
+		ptr, ok := rr.(*dns.PTR)
+		if !ok || !slices.Contains(BrowseServices, ptr.Hdr.Name) {
+			continue
+		}
+
+		service := MDNSService{
+			Type: strings.TrimSuffix(ptr.Hdr.Name, ".local."),
+		}
+
+		// ex. "Front\ Door._rtsp._tcp.local." -> "Front Door"
+		if i := strings.Index(ptr.Ptr, "."+ptr.Hdr.Name); i > 0 {
+			service.Name = strings.ReplaceAll(ptr.Ptr[:i], `\ `, " ")
+		}
+
+		for _, rr := range records {
+			if srv, ok := rr.(*dns.SRV); ok && srv.Hdr.Name == ptr.Ptr {
+				service.Port = int(srv.Port)
+				if device.Hostname == "" {
+					device.Hostname = strings.TrimSuffix(srv.Target, ".")
+				}
+				break
+			}
+		}
+
+		if !slices.Contains(device.Services, service) {
+			device.Services = append(device.Services, service)
+			found = true
+		}
+	}
+
+	return found
+}
+
 func parseHAPResponse(msg *dns.Msg) (*MDNSResult, error) {
 	records := make([]dns.RR, 0, len(msg.Answer)+len(msg.Extra))
 	records = append(records, msg.Answer...)
@@ -0,0 +1,72 @@
+package probe
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestParseBrowseResponse(t *testing.T) {
+	rr := func(s string) dns.RR {
+		r, err := dns.NewRR(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	msg := &dns.Msg{
+		MsgHdr: dns.MsgHdr{Response: true, Authoritative: true},
+		Answer: []dns.RR{
+			rr(`_rtsp._tcp.local. 120 IN PTR Front\ Door._rtsp._tcp.local.`),
+			rr(`_http._tcp.local. 120 IN PTR Front\ Door._http._tcp.local.`),
+			rr(`_printer._tcp.local. 120 IN PTR Office._printer._tcp.local.`),
+		},
+		Extra: []dns.RR{
+			rr(`Front\ Door._rtsp._tcp.local. 120 IN SRV 0 0 554 cam-1a2b.local.`),
+			rr(`Front\ Door._http._tcp.local. 120 IN SRV 0 0 80 cam-1a2b.local.`),
+			rr(`cam-1a2b.local. 120 IN A 192.168.1.120`),
+		},
+	}
+
+	// fixture goes through wire format, same as multicast answer
+	b, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp dns.Msg
+	if err = resp.Unpack(b); err != nil {
+		t.Fatal(err)
+	}
+
+	device := &MDNSDevice{IP: "192.168.1.120"}
+	if !parseBrowseResponse(&resp, device) {
+		t.Fatal("no services found")
+	}
+
+	want := &MDNSDevice{
+		IP:       "192.168.1.120",
+		Hostname: "cam-1a2b.local",
+		Services: []MDNSService{
+			{Name: "Front Door", Type: "_rtsp._tcp", Port: 554},
+			{Name: "Front Door", Type: "_http._tcp", Port: 80},
+		},
+	}
+	if !reflect.DeepEqual(device, want) {
+		t.Errorf("got %+v, want %+v", device, want)
+	}
+
+	// repeated answer adds nothing
+	if parseBrowseResponse(&resp, device) {
+		t.Error("repeated answer reported as new")
+	}
+	if len(device.Services) != 2 {
+		t.Errorf("services = %v", device.Services)
+	}
+
+	other := &dns.Msg{Answer: []dns.RR{rr(`_printer._tcp.local. 120 IN PTR Office._printer._tcp.local.`)}}
+	if parseBrowseResponse(other, &MDNSDevice{}) {
+		t.Error("unknown service reported")
+	}
+}
@@ -1,11 +1,20 @@
 package probe
 
 type Response struct {
-	IP        string  `json:"ip"`
-	Reachable bool    `json:"reachable"`
-	Type string `json:"type"` // "unreachable", "standard", "homekit"
-	Error     string  `json:"error,omitempty"`
-	Probes    Probes  `json:"probes"`
+	IP        string       `json:"ip"`
+	Host      string       `json:"host,omitempty"` // hostname from request, ex. "camera.local"
+	Reachable bool         `json:"reachable"`
+	Type      string       `json:"type"` // "unreachable", "standard", "homekit"
+	Error     string       `json:"error,omitempty"`
+	Probes    Probes       `json:"probes"`
+	Brand     *BrandResult `json:"brand,omitempty"` // best guess from probes, see GuessBrand
+}
+
+type BrandResult struct {
+	ID         string   `json:"id"` // for /api/streams ids, ex. "b:hikvision"
+	Name       string   `json:"name"`
+	Confidence float64  `json:"confidence"` // 0..1
+	Sources    []string `json:"sources"`    // probes that agree, ex. ["arp", "http"]
 }
 
 type Probes struct {
@@ -39,10 +48,24 @@ type MDNSResult struct {
 	Port     int    `json:"port"`
 }
 
+// MDNSDevice -- device announced with DNS-SD, see BrowseMDNS
+type MDNSDevice struct {
+	IP       string        `json:"ip"`
+	Hostname string        `json:"hostname,omitempty"` // ex. "camera-1a2b.local"
+	Services []MDNSService `json:"services"`
+}
+
+type MDNSService struct {
+	Name string `json:"name"` // instance name, ex. "Front Door"
+	Type string `json:"type"` // ex. "_rtsp._tcp"
+	Port int    `json:"port,omitempty"`
+}
+
 type HTTPResult struct {
 	Port       int    `json:"port"`
 	StatusCode int    `json:"status_code"`
 	Server     string `json:"server"`
+	Realm      string `json:"realm,omitempty"` // WWW-Authenticate realm, often brand or model
 }
 
 type ONVIFResult struct {
@@ -5,7 +5,9 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
@@ -88,8 +90,71 @@ func ProbeONVIF(ctx context.Context, ip string) (*ONVIFResult, error) {
 	}
 }
 
+// ProbeONVIFPorts sends unauthenticated GetSystemDateAndTime to device service
+// on each port. Fallback for cameras with WS-Discovery disabled.
+// Returns nil, nil if no port answers as ONVIF device.
+func ProbeONVIFPorts(ctx context.Context, ip string, ports []int) (*ONVIFResult, error) {
+	const body = `<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+	<s:Body>
+		<GetSystemDateAndTime xmlns="http://www.onvif.org/ver10/device/wsdl"/>
+	</s:Body>
+</s:Envelope>`
+
+	ch := make(chan *ONVIFResult, len(ports))
+
+	for _, port := range ports {
+		go func(port int) {
+			deviceURL := fmt.Sprintf("http://%s:%d/onvif/device_service", ip, port)
+			req, err := http.NewRequestWithContext(ctx, "POST", deviceURL, strings.NewReader(body))
+			if err != nil {
+				ch <- nil
+				return
+			}
+			req.Header.Set("Content-Type", "application/soap+xml; charset=utf-8")
+
+			resp, err := onvifClient.Do(req)
+			if err != nil {
+				ch <- nil
+				return
+			}
+			b, _ := io.ReadAll(io.LimitReader(resp.Body, 8192))
+			resp.Body.Close()
+
+			if !strings.Contains(string(b), "SystemDateAndTime") {
+				ch <- nil
+				return
+			}
+			ch <- &ONVIFResult{URL: deviceURL, Port: port}
+		}(port)
+	}
+
+	for range ports {
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case r := <-ch:
+			if r != nil {
+				return r, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
 // internals
 
+// onvifClient -- own transport without HTTP_PROXY, probe must see local network, not jump host.
+// Shared by all probes, without keep-alive: each port is asked once, idle connections
+// would pile up during port sweeps.
+var onvifClient = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+	Transport: &http.Transport{DisableKeepAlives: true},
+}
+
 var reXMLTag = map[string]*regexp.Regexp{}
 
 func findXMLTag(s, tag string) string {
@@ -0,0 +1,60 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProbeONVIFPorts(t *testing.T) {
+	var opened, closed atomic.Int32
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/onvif/device_service" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<SOAP-ENV:Envelope><SOAP-ENV:Body><tds:GetSystemDateAndTimeResponse>` +
+			`<tds:SystemDateAndTime></tds:SystemDateAndTime></tds:GetSystemDateAndTimeResponse></SOAP-ENV:Body></SOAP-ENV:Envelope>`))
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			opened.Add(1)
+		case http.StateClosed:
+			closed.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	// port sweep, only one port answers
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		r, err := ProbeONVIFPorts(ctx, u.Hostname(), []int{port, 1})
+		cancel()
+
+		if err != nil || r == nil || r.Port != port {
+			t.Fatalf("got %+v, %v", r, err)
+		}
+	}
+
+	// connections are not kept idle
+	deadline := time.Now().Add(time.Second)
+	for closed.Load() < opened.Load() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if o, c := opened.Load(), closed.Load(); o != c {
+		t.Errorf("%d connections opened, %d closed", o, c)
+	}
+}
@@ -0,0 +1,64 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// runClean runs test in a new process with env, Go reads proxy env once per process
+func runClean(t *testing.T, name string) bool {
+	if os.Getenv("STRIX_TEST_CLEAN") == "1" {
+		return false
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^"+name+"$", "-test.v")
+	cmd.Env = append(os.Environ(), "STRIX_TEST_CLEAN=1")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	return true
+}
+
+func TestProbeNoProxy(t *testing.T) {
+	if runClean(t, "TestProbeNoProxy") {
+		return
+	}
+
+	var requests atomic.Int32
+
+	// jump host answers as HTTP and ONVIF camera
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Server", "proxy")
+		_, _ = w.Write([]byte(`<tds:SystemDateAndTime></tds:SystemDateAndTime>`))
+	}))
+	defer proxy.Close()
+
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	t.Setenv("HTTPS_PROXY", proxy.URL)
+
+	// TEST-NET address, unreachable without proxy
+	const ip = "192.0.2.1"
+
+	// each probe waits for unreachable IP until timeout
+	timeout := func() context.Context {
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		t.Cleanup(cancel)
+		return ctx
+	}
+
+	if r, _ := ProbeHTTP(timeout(), ip, []int{80}); r != nil {
+		t.Errorf("http: got %+v through proxy", r)
+	}
+	if r, _ := ProbeONVIFPorts(timeout(), ip, []int{80}); r != nil {
+		t.Errorf("onvif: got %+v through proxy", r)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("proxy got %d requests, probe must use local network", n)
+	}
+}
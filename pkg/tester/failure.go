@@ -0,0 +1,116 @@
+package tester
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"syscall"
+)
+
+// failure reasons, see failureReason
+const (
+	FailAuth      = "auth"
+	FailRefused   = "refused"
+	FailTimeout   = "timeout"
+	FailNotFound  = "not_found"
+	FailRateLimit = "rate_limited"
+	FailMalformed = "malformed"
+	FailWebUI     = "web_ui"
+	FailOther     = "other"
+)
+
+// errMalformed -- URL can't be tested, ex. broken database pattern
+var errMalformed = errors.New("malformed candidate URL")
+
+// errDenied -- URL path is in deny list, see DenyPaths. Counted as skipped, not failure
+var errDenied = errors.New("path is in deny list")
+
+// errWebUI -- HTTP URL answers with HTML page, ex. camera or router login page
+var errWebUI = errors.New("http: web interface, not a stream")
+
+// errJSON -- HTTP URL answers with JSON, usually API error with 200 status
+var errJSON = errors.New("http: json response, not a stream")
+
+// failureReason classifies test error from go2rtc handlers
+func failureReason(err error) string {
+	if errors.Is(err, errMalformed) {
+		return FailMalformed
+	}
+	if errors.Is(err, errWebUI) {
+		return FailWebUI
+	}
+	var ra *retryAfterError
+	if errors.As(err, &ra) {
+		return FailRateLimit
+	}
+	if errors.Is(err, errJSON) {
+		if reAuthError.MatchString(err.Error()) {
+			return FailAuth
+		}
+		return FailOther
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		switch se.code {
+		case http.StatusUnauthorized:
+			return FailAuth
+		case http.StatusNotFound:
+			return FailNotFound
+		}
+		return FailOther
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return FailRefused
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return FailTimeout
+	}
+
+	// status text, not bare digits, ex. "dial tcp 10.0.0.5:8401" is not auth
+	s := err.Error()
+	switch {
+	case strings.Contains(s, "user/pass"), strings.Contains(s, " 401 Unauthorized"),
+		strings.Contains(s, "unsupported auth"):
+		return FailAuth
+	case strings.Contains(s, "connection refused"):
+		return FailRefused
+	case strings.Contains(s, "timeout"), strings.Contains(s, "deadline exceeded"):
+		return FailTimeout
+	case strings.Contains(s, " 404 Not Found"):
+		return FailNotFound
+	}
+	return FailOther
+}
+
+// reAuthError matches auth problems in JSON errors, ex. "auth", "Unauthorized", "invalid password", "401"
+var reAuthError = regexp.MustCompile(`(?i)auth|login|password|\b40[13]\b`)
+
+// failureHints suggests what to check when nothing was found
+func failureHints(failures map[string]int) []string {
+	var hints []string
+
+	if failures[FailAuth] > 0 {
+		hints = append(hints, "camera rejected credentials: check username and password")
+	}
+
+	total := 0
+	for _, n := range failures {
+		total += n
+	}
+	if total > 0 && failures[FailRefused]+failures[FailTimeout] == total {
+		hints = append(hints, "camera doesn't answer on tested ports: check IP address and network")
+	}
+	if failures[FailWebUI] > 0 && failures[FailWebUI] >= total/2 {
+		hints = append(hints, "HTTP URLs return web pages, not video: try RTSP or ONVIF")
+	}
+	if failures[FailRateLimit] > 0 {
+		hints = append(hints, "camera or proxy limits request rate: test again later or lower host_limit")
+	}
+	if failures[FailNotFound] > total/2 {
+		hints = append(hints, "camera answers but paths are unknown: try another brand, model or ONVIF")
+	}
+
+	return hints
+}
@@ -0,0 +1,51 @@
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFailureReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("rtsp: describe: %w", errors.New("wrong user/pass")), FailAuth},
+		{errors.New("rtsp: describe: user/pass not provided"), FailAuth},
+		{errors.New("onvif: wrong response 401 Unauthorized"), FailAuth},
+		{&statusError{code: 401, status: "401 Unauthorized"}, FailAuth},
+		{fmt.Errorf("%w: %s", errJSON, `{"error":"invalid password"}`), FailAuth},
+		{fmt.Errorf("%w: %s", errJSON, `{"code":403}`), FailAuth},
+
+		{&statusError{code: 404, status: "404 Not Found"}, FailNotFound},
+		{errors.New("onvif: wrong response 404 Not Found"), FailNotFound},
+		{&statusError{code: 500, status: "500 Internal Server Error"}, FailOther},
+
+		// digits in address are not status codes
+		{errors.New("dial tcp 10.0.0.5:8401: connect: connection refused"), FailRefused},
+		{errors.New("dial tcp 10.0.0.5:8404: i/o timeout"), FailTimeout},
+		{errors.New("rtsp: dial: 192.168.1.104:404: no route to host"), FailOther},
+		{fmt.Errorf("%w: %s", errJSON, `{"id":84010}`), FailOther},
+
+		{&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, FailRefused},
+		{fmt.Errorf("rtsp: dial: %w", os.ErrDeadlineExceeded), FailTimeout},
+		{context.DeadlineExceeded, FailTimeout},
+
+		{fmt.Errorf("%w: %s", errMalformed, "{ip}"), FailMalformed},
+		{errWebUI, FailWebUI},
+		{&retryAfterError{delay: time.Second}, FailRateLimit},
+		{errors.New("wrong response on DESCRIBE"), FailOther},
+	}
+
+	for _, test := range tests {
+		if got := failureReason(test.err); got != test.want {
+			t.Errorf("failureReason(%q) = %q, want %q", test.err, got, test.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package tester
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/AlexxIT/go2rtc/pkg/bubble"
 	"github.com/AlexxIT/go2rtc/pkg/core"
@@ -56,12 +57,28 @@ func rtmpHandler(rawURL string) (core.Producer, error) {
 	return rtmp.DialPlay(rawURL)
 }
 
+// RTSPTimeout limits RTSP connect and each RTSP request, change with SetRTSPTimeout
+var RTSPTimeout = rtsp.Timeout
+
+// SetRTSPTimeout changes RTSP timeout, go2rtc uses its own global for request deadlines.
+// It is process-wide, not per session: all sessions and go2rtc RTSP clients in the
+// process get it. Call once at startup, before tests are started.
+func SetRTSPTimeout(d time.Duration) {
+	RTSPTimeout = d
+	rtsp.Timeout = d
+}
+
+// RTSPTransport -- go2rtc RTSP client always reads media interleaved over the RTSP
+// TCP connection, so alive RTSP streams are validated with TCP transport
+const RTSPTransport = "tcp"
+
 // rtspHandler -- Dial + Describe. Proves: port open, RTSP responds, auth OK, SDP received.
 func rtspHandler(rawURL string) (core.Producer, error) {
 	rawURL, _, _ = strings.Cut(rawURL, "#")
 
 	conn := rtsp.NewClient(rawURL)
 	conn.Backchannel = false
+	conn.Timeout = max(int(RTSPTimeout/time.Second), 1) // seconds
 
 	if err := conn.Dial(); err != nil {
 		return nil, fmt.Errorf("rtsp: dial: %w", err)
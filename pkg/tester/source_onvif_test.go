@@ -0,0 +1,66 @@
+package tester
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryONVIF(t *testing.T) {
+	errBusy := errors.New("onvif: read tcp 10.0.0.5:80: connection reset by peer")
+	errAuth := errors.New("onvif: wrong response 401 Unauthorized")
+	errRefused := errors.New("dial tcp 10.0.0.5:80: connect: connection refused")
+
+	tests := []struct {
+		name  string
+		errs  []error // answers of fake resolver, nil is success
+		calls int
+		ok    bool
+	}{
+		{"fail once", []error{errBusy, nil}, 2, true},
+		{"always busy", []error{errBusy, errBusy}, onvifAttempts, false},
+		{"auth", []error{errAuth, nil}, 1, false},
+		{"refused", []error{errRefused, nil}, 1, false},
+		{"ok", []error{nil}, 1, true},
+	}
+
+	for _, test := range tests {
+		var calls int
+		resolve := func() (string, error) {
+			err := test.errs[calls]
+			calls++
+			if err != nil {
+				return "", err
+			}
+			return "rtsp://10.0.0.5/live", nil
+		}
+
+		uri, err := retryONVIF(NewSession("test", 1), resolve)
+
+		if calls != test.calls {
+			t.Errorf("%s: calls = %d, want %d", test.name, calls, test.calls)
+		}
+		if ok := err == nil && uri == "rtsp://10.0.0.5/live"; ok != test.ok {
+			t.Errorf("%s: uri = %q, err = %v", test.name, uri, err)
+		}
+	}
+}
+
+func TestRetryONVIFCancel(t *testing.T) {
+	s := NewSession("test", 1)
+	s.Cancel()
+
+	var calls int
+	start := time.Now()
+	_, _ = retryONVIF(s, func() (string, error) {
+		calls++
+		return "", errors.New("onvif: connection reset by peer")
+	})
+
+	if calls != 1 {
+		t.Errorf("calls = %d, want no retry after cancel", calls)
+	}
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Errorf("cancelled retry waits %s", d)
+	}
+}
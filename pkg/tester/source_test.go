@@ -0,0 +1,47 @@
+package tester
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/AlexxIT/go2rtc/pkg/rtsp"
+)
+
+func TestSetRTSPTimeout(t *testing.T) {
+	defer SetRTSPTimeout(RTSPTimeout)
+	SetRTSPTimeout(time.Second)
+
+	if rtsp.Timeout != time.Second {
+		t.Errorf("go2rtc timeout = %s", rtsp.Timeout)
+	}
+
+	// accepts connection, never answers DESCRIBE
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Read(make([]byte, 4096))
+		time.Sleep(5 * time.Second)
+	}()
+
+	start := time.Now()
+	_, err = rtspHandler("rtsp://" + ln.Addr().String() + "/live")
+	if err == nil {
+		t.Fatal("silent server answered")
+	}
+	if d := time.Since(start); d > 3*time.Second {
+		t.Errorf("timeout after %s, want about 1s", d)
+	}
+	if reason := failureReason(err); reason != FailTimeout {
+		t.Errorf("reason = %q for %v", reason, err)
+	}
+}
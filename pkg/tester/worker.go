@@ -2,9 +2,15 @@ package tester
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"net/url"
 	"os/exec"
+	"reflect"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/AlexxIT/go2rtc/pkg/core"
@@ -13,10 +19,29 @@ import (
 
 const workers = 20
 
+// WorkerStagger delays start of each next worker, with small random jitter.
+// Smooths connection burst on NVRs with connection limits, 0 - start all at once
+var WorkerStagger time.Duration
+
+// RetryAfterMax limits how long a rate limited host is deferred, longer
+// Retry-After fails the test. Session max duration still applies
+var RetryAfterMax = time.Minute
+
 func RunWorkers(s *Session, urls []string) {
 	ch := make(chan string, len(urls))
+
+	// onvif:// first, its stream URIs are claimed before database URLs of the same host
+	// are tested, so the same stream is not tested twice, see ClaimONVIF
 	for _, u := range urls {
-		ch <- u
+		if strings.HasPrefix(u, "onvif://") {
+			s.ExpectONVIF(urlHost(u))
+			ch <- u
+		}
+	}
+	for _, u := range urls {
+		if !strings.HasPrefix(u, "onvif://") {
+			ch <- u
+		}
 	}
 	close(ch)
 
@@ -27,17 +52,105 @@ func RunWorkers(s *Session, urls []string) {
 		n = len(urls)
 	}
 
+	// per-host limit, reduces load on cameras and NVRs with connection limits
+	var hostMu sync.Mutex
+	hosts := map[string]chan struct{}{}
+
+	// acquire waits for free slot of URL host, false if session was cancelled while waiting
+	acquire := func(rawURL string) (func(), bool) {
+		if s.HostLimit <= 0 {
+			return func() {}, true
+		}
+
+		host := urlHost(rawURL)
+
+		hostMu.Lock()
+		sem, ok := hosts[host]
+		if !ok {
+			sem = make(chan struct{}, s.HostLimit)
+			hosts[host] = sem
+		}
+		hostMu.Unlock()
+
+		select {
+		case sem <- struct{}{}:
+			return func() { <-sem }, true
+		case <-s.Cancelled():
+			return nil, false
+		}
+	}
+
+	// URLs of hosts with unsettled onvif:// sources are put aside until the queue is empty,
+	// so workers don't wait for one host while URLs of other hosts are queued behind them
+	later := make(chan string, len(urls))
+	var firstPass sync.WaitGroup
+	firstPass.Add(n)
+	go func() {
+		firstPass.Wait()
+		close(later)
+	}()
+
+	// run tests URL in a free slot of its host, false if session was cancelled
+	run := func(rawURL string) bool {
+		release, ok := acquire(rawURL)
+		if !ok {
+			return false
+		}
+		// session may be stopped while waiting for busy host
+		select {
+		case <-s.Cancelled():
+			release()
+			return false
+		default:
+		}
+		testURL(s, rawURL)
+		release()
+		return true
+	}
+
 	for i := 0; i < n; i++ {
 		go func() {
+			defer func() { done <- struct{}{} }()
+
+			passed := sync.OnceFunc(firstPass.Done)
+			defer passed()
+
+			if WorkerStagger > 0 && i > 0 {
+				delay := time.Duration(i)*WorkerStagger + rand.N(WorkerStagger/2+1)
+				select {
+				case <-time.After(delay):
+				case <-s.Cancelled():
+					return
+				}
+			}
+
 			for rawURL := range ch {
 				select {
 				case <-s.Cancelled():
 					return
 				default:
 				}
-				testURL(s, rawURL)
+				if !strings.HasPrefix(rawURL, "onvif://") && s.PendingONVIF(urlHost(rawURL)) {
+					later <- rawURL
+					continue
+				}
+				if !run(rawURL) {
+					return
+				}
+			}
+
+			passed()
+
+			for rawURL := range later {
+				// before acquire, ONVIF source of this host may wait for the same slot.
+				// Limited by ONVIFTimeout, see testOnvif
+				if !s.WaitONVIF(urlHost(rawURL)) {
+					return
+				}
+				if !run(rawURL) {
+					return
+				}
 			}
-			done <- struct{}{}
 		}()
 	}
 
@@ -51,13 +164,33 @@ func RunWorkers(s *Session, urls []string) {
 func testURL(s *Session, rawURL string) {
 	defer s.AddTested()
 
+	// fail fast instead of waiting for dial timeout on broken URL
+	if err := checkURL(rawURL); err != nil {
+		if strings.HasPrefix(rawURL, "onvif://") {
+			s.SettleONVIF(urlHost(rawURL))
+		}
+		if errors.Is(err, errDenied) {
+			s.AddSkipped()
+			return
+		}
+		s.AddFailure(rawURL, err)
+		return
+	}
+
 	if strings.HasPrefix(rawURL, "homekit://") {
 		testHomeKit(s, rawURL)
 		return
 	}
 
 	if strings.HasPrefix(rawURL, "onvif://") {
-		testOnvif(s, rawURL)
+		settle := sync.OnceFunc(func() { s.SettleONVIF(urlHost(rawURL)) })
+		defer settle()
+		testOnvif(s, rawURL, settle)
+		return
+	}
+
+	// same stream was already tested by ONVIF with its own URI
+	if s.ClaimedByONVIF(rawURL) {
 		return
 	}
 
@@ -66,10 +199,34 @@ func testURL(s *Session, rawURL string) {
 		return
 	}
 
-	start := time.Now()
+	host := urlHost(rawURL)
 
-	prod, err := handler(rawURL)
+	var start time.Time
+	var prod core.Producer
+	var err error
+
+	// one retry after rate limit, other tests to this host wait too
+	for attempt := 0; attempt < 2; attempt++ {
+		if !s.WaitHost(host) {
+			return
+		}
+
+		start = time.Now()
+
+		if s.Headers != nil && strings.HasPrefix(rawURL, "http") {
+			prod, err = openHTTP(rawURL, s.Headers)
+		} else {
+			prod, err = handler(rawURL)
+		}
+
+		var ra *retryAfterError
+		if !errors.As(err, &ra) || ra.delay > RetryAfterMax {
+			break
+		}
+		s.DeferHost(host, time.Now().Add(ra.delay))
+	}
 	if err != nil {
+		s.AddFailure(rawURL, err)
 		return
 	}
 	defer func() { _ = prod.Stop() }()
@@ -88,10 +245,16 @@ func testURL(s *Session, rawURL string) {
 
 	r := &Result{
 		Source:    rawURL,
-		Codecs:   codecs,
+		Format:    formatName(prod),
+		Codecs:    codecs,
 		LatencyMs: latency,
 	}
 
+	if s.Mode == ModeQuick || s.NoScreens {
+		s.AddResult(r)
+		return
+	}
+
 	if raw, codecName := getScreenshot(prod); raw != nil {
 		var jpeg []byte
 
@@ -114,6 +277,14 @@ func testURL(s *Session, rawURL string) {
 	s.AddResult(r)
 }
 
+// urlHost returns hostname from stream URL, or whole URL if it can't be parsed
+func urlHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return rawURL
+}
+
 // getScreenshot connects Keyframe consumer to producer, waits for first keyframe with 10s timeout
 func getScreenshot(prod core.Producer) ([]byte, string) {
 	cons := magic.NewKeyframe()
@@ -186,6 +357,44 @@ func jpegSize(data []byte) (int, int) {
 	return 0, 0
 }
 
+// DenyPaths -- URLs with matching path and query are never tested,
+// ex. paths that reboot or crash some firmware
+var DenyPaths *regexp.Regexp
+
+// checkURL rejects URLs that can't be dialed or denied, ex. "rtsp://192.168.1.100/live main"
+func checkURL(rawURL string) error {
+	if strings.ContainsAny(rawURL, " \t\r\n") {
+		return fmt.Errorf("%w: whitespace", errMalformed)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errMalformed, err)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%w: no host", errMalformed)
+	}
+	if DenyPaths != nil && DenyPaths.MatchString(u.RequestURI()) {
+		return errDenied
+	}
+	return nil
+}
+
+// formatName returns FFmpeg compatible format of go2rtc producer, ex. "rtsp", "mpjpeg", "hls/mpegts".
+// All producers embed core.Connection, but there is no getter for it.
+func formatName(prod core.Producer) string {
+	v := reflect.ValueOf(prod)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	if f := v.FieldByName("FormatName"); f.Kind() == reflect.String {
+		return f.String()
+	}
+	return ""
+}
+
 func toJPEG(raw []byte) []byte {
 	cmd := exec.Command("ffmpeg",
 		"-hide_banner", "-loglevel", "error",
@@ -202,3 +411,23 @@ func toJPEG(raw []byte) []byte {
 	}
 	return out
 }
+
+var ffmpegOnce sync.Once
+var ffmpegVersion string
+
+// FFmpegVersion returns version of ffmpeg used for screenshots, empty if not found.
+// Result is cached, ex. "6.1.1-3ubuntu5" or "n7.0-static"
+func FFmpegVersion() string {
+	ffmpegOnce.Do(func() {
+		out, err := exec.Command("ffmpeg", "-version").Output()
+		if err != nil {
+			return
+		}
+		// ex. "ffmpeg version 6.1.1-3ubuntu5 Copyright (c) 2000-2023 ..."
+		line, _, _ := strings.Cut(string(out), "\n")
+		if fields := strings.Fields(line); len(fields) >= 3 && fields[1] == "version" {
+			ffmpegVersion = fields[2]
+		}
+	})
+	return ffmpegVersion
+}
@@ -13,12 +13,14 @@ func testHomeKit(s *Session, rawURL string) {
 
 	conn, err := hap.Dial(rawURL)
 	if err != nil {
+		s.AddFailure(rawURL, err)
 		return
 	}
 	defer conn.Close()
 
 	jpeg, err := conn.GetImage(1920, 1080)
 	if err != nil {
+		s.AddFailure(rawURL, err)
 		return
 	}
 